@@ -6,9 +6,17 @@ import (
 
 	"yambot/pkg/config"
 	"yambot/pkg/discord"
+	"yambot/pkg/outbox"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "outbox" {
+		if err := runOutbox(os.Args[2:]); err != nil {
+			log.Fatalf("Outbox: %v", err)
+		}
+		return
+	}
+
 	configPath := "cmd/config.yml"
 	if len(os.Args) > 1 {
 		configPath = os.Args[1]
@@ -25,6 +33,16 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to create Discord bot: %v", err)
 	}
+	bot.ConfigPath = configPath
+
+	if cfg.Bot.Outbox.Enabled() {
+		store, err := outbox.Open(cfg.Bot.Outbox.Dir)
+		if err != nil {
+			log.Fatalf("Failed to open outbox: %v", err)
+		}
+		defer store.Close()
+		bot.Outbox = store
+	}
 
 	if err := bot.Start(); err != nil {
 		log.Fatalf("Failed to start bot: %v", err)
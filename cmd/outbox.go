@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"yambot/pkg/config"
+	"yambot/pkg/outbox"
+)
+
+const outboxUsage = `Usage: yambot outbox [-config path | -dir path] <command>
+
+Commands:
+  pending           list payloads waiting for delivery
+  list              list dead-letter payloads
+  show <id>         print a dead-letter payload
+  replay <id>...    move dead-letter payloads back to the pending list
+  replay all        move every dead-letter payload back to the pending list
+
+The outbox can only be opened by one process at a time: stop the bot first.
+Replayed payloads are delivered when the bot starts again.
+`
+
+// runOutbox implements the "outbox" subcommand for inspecting and replaying
+// undelivered webhook payloads.
+func runOutbox(args []string) error {
+	fs := flag.NewFlagSet("outbox", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprint(fs.Output(), outboxUsage) }
+	configPath := fs.String("config", "cmd/config.yml", "config file naming the outbox directory")
+	dir := fs.String("dir", "", "outbox directory, instead of reading it from the config")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("missing outbox command")
+	}
+
+	if *dir == "" {
+		cfg, err := config.LoadConfig(*configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if !cfg.Bot.Outbox.Enabled() {
+			return fmt.Errorf("bot.outbox.dir is not set in %s", *configPath)
+		}
+		*dir = cfg.Bot.Outbox.Dir
+	}
+
+	store, err := outbox.Open(*dir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	switch command := fs.Arg(0); command {
+	case "pending":
+		entries, err := store.Pending()
+		if err != nil {
+			return err
+		}
+		printEntries(entries)
+	case "list":
+		entries, err := store.Dead()
+		if err != nil {
+			return err
+		}
+		printEntries(entries)
+	case "show":
+		if fs.NArg() != 2 {
+			return errors.New("show needs exactly one entry ID")
+		}
+		entries, err := store.Dead()
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.ID == fs.Arg(1) {
+				if entry.Body != nil {
+					// Multipart uploads are printed as sent
+					os.Stdout.Write(entry.Body)
+					return nil
+				}
+				fmt.Printf("%s\n", entry.Payload)
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: %w", fs.Arg(1), outbox.ErrNotFound)
+	case "replay":
+		ids := fs.Args()[1:]
+		if len(ids) == 0 {
+			return errors.New("replay needs entry IDs or \"all\"")
+		}
+		if len(ids) == 1 && ids[0] == "all" {
+			entries, err := store.Dead()
+			if err != nil {
+				return err
+			}
+			ids = ids[:0]
+			for _, entry := range entries {
+				ids = append(ids, entry.ID)
+			}
+		}
+		for _, id := range ids {
+			if err := store.Replay(id); err != nil {
+				return fmt.Errorf("%s: %w", id, err)
+			}
+			fmt.Printf("Replaying %s\n", id)
+		}
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown outbox command %q", command)
+	}
+
+	return nil
+}
+
+func printEntries(entries []outbox.Entry) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCOMMAND\tATTEMPTS\tCREATED\tURL\tLAST ERROR")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", entry.ID, entry.Command, entry.Attempts, entry.CreatedAt.Format(time.RFC3339), entry.URL, entry.LastError)
+	}
+	w.Flush()
+}
@@ -0,0 +1,75 @@
+package config
+
+// AccessSpec restricts who can run a command and where. Deny lists always win;
+// when an allow list is set, the user, role or channel must be on it.
+type AccessSpec struct {
+	AllowRoles    []string `yaml:"allow_roles,omitempty"`
+	DenyRoles     []string `yaml:"deny_roles,omitempty"`
+	AllowUsers    []string `yaml:"allow_users,omitempty"`
+	DenyUsers     []string `yaml:"deny_users,omitempty"`
+	AllowChannels []string `yaml:"allow_channels,omitempty"`
+	DenyChannels  []string `yaml:"deny_channels,omitempty"`
+	// Permissions are Discord permission names the member must hold. They are
+	// also registered as the command's default member permissions, so Discord
+	// hides the command from members without them, unless an allow list is
+	// set (see DefaultMemberPermissions).
+	Permissions []string `yaml:"permissions,omitempty"`
+	// Message replaces the default denial message.
+	Message string `yaml:"message,omitempty"`
+}
+
+// permissionFlags maps permission names to Discord's permission bits.
+var permissionFlags = map[string]int64{
+	"create_instant_invite":    1 << 0,
+	"kick_members":             1 << 1,
+	"ban_members":              1 << 2,
+	"administrator":            1 << 3,
+	"manage_channels":          1 << 4,
+	"manage_guild":             1 << 5,
+	"add_reactions":            1 << 6,
+	"view_audit_log":           1 << 7,
+	"view_channel":             1 << 10,
+	"send_messages":            1 << 11,
+	"manage_messages":          1 << 13,
+	"embed_links":              1 << 14,
+	"attach_files":             1 << 15,
+	"read_message_history":     1 << 16,
+	"mention_everyone":         1 << 17,
+	"view_guild_insights":      1 << 19,
+	"change_nickname":          1 << 26,
+	"manage_nicknames":         1 << 27,
+	"manage_roles":             1 << 28,
+	"manage_webhooks":          1 << 29,
+	"manage_expressions":       1 << 30,
+	"use_application_commands": 1 << 31,
+	"manage_events":            1 << 33,
+	"manage_threads":           1 << 34,
+	"moderate_members":         1 << 40,
+}
+
+// PermissionMask returns the combined permission bits of a.Permissions, or
+// nil when no permissions are required.
+func (a *AccessSpec) PermissionMask() *int64 {
+	if a == nil || len(a.Permissions) == 0 {
+		return nil
+	}
+
+	var mask int64
+	for _, name := range a.Permissions {
+		mask |= permissionFlags[name]
+	}
+	return &mask
+}
+
+// DefaultMemberPermissions returns the default member permissions a command
+// is registered with. Discord cannot express role, user or channel allow
+// lists, so a command with one is hidden from everyone but administrators,
+// who grant access under Server Settings → Integrations. Otherwise it is the
+// PermissionMask.
+func (a *AccessSpec) DefaultMemberPermissions() *int64 {
+	if a != nil && (len(a.AllowRoles) > 0 || len(a.AllowUsers) > 0 || len(a.AllowChannels) > 0) {
+		var none int64
+		return &none
+	}
+	return a.PermissionMask()
+}
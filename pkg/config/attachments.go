@@ -0,0 +1,78 @@
+package config
+
+import (
+	"mime"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultAttachmentMaxSize is the largest file downloaded when a command sets
+// no max_size.
+const DefaultAttachmentMaxSize = 10 << 20
+
+// AttachmentModes lists the names accepted in an attachments block's mode.
+var AttachmentModes = []string{"link", "forward", "base64"}
+
+// AttachmentSpec controls how the files uploaded with a command reach its
+// webhook. In YAML it is either a mode name or a block.
+type AttachmentSpec struct {
+	// Mode is link (the default) to send only the file details and Discord
+	// URL, forward to upload the files as multipart/form-data parts, or
+	// base64 to embed their contents in the JSON payload.
+	Mode string `yaml:"mode"`
+	// MaxSize is the largest file in bytes that is accepted for forward and
+	// base64 modes.
+	MaxSize int64 `yaml:"max_size,omitempty"`
+	// ContentTypes restricts the accepted files to these media types, such
+	// as "application/pdf" or "image/*".
+	ContentTypes []string `yaml:"content_types,omitempty"`
+}
+
+// UnmarshalYAML accepts either a mode name or an attachments block.
+func (a *AttachmentSpec) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*a = AttachmentSpec{}
+		return node.Decode(&a.Mode)
+	}
+
+	type plain AttachmentSpec
+	return node.Decode((*plain)(a))
+}
+
+// Downloads reports whether files are downloaded and sent to the webhook.
+func (a *AttachmentSpec) Downloads() bool {
+	return a != nil && (a.Mode == "forward" || a.Mode == "base64")
+}
+
+// Limit returns the largest accepted file size in bytes.
+func (a *AttachmentSpec) Limit() int64 {
+	if a == nil || a.MaxSize == 0 {
+		return DefaultAttachmentMaxSize
+	}
+	return a.MaxSize
+}
+
+// Allows reports whether a file of the given content type is accepted. All
+// types are accepted when no content_types are configured.
+func (a *AttachmentSpec) Allows(contentType string) bool {
+	if a == nil || len(a.ContentTypes) == 0 {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range a.ContentTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestAttachmentSpec(t *testing.T) {
+	var unset *AttachmentSpec
+	if unset.Downloads() || unset.Limit() != DefaultAttachmentMaxSize || !unset.Allows("application/zip") {
+		t.Error("Expected no attachments block to keep links, with the default limit and any type")
+	}
+
+	spec := &AttachmentSpec{Mode: "forward", MaxSize: 1024, ContentTypes: []string{"application/pdf", "image/*"}}
+	if !spec.Downloads() || spec.Limit() != 1024 {
+		t.Errorf("Expected forward mode with a 1024 byte limit, got %+v", spec)
+	}
+
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/pdf", true},
+		{"image/png", true},
+		{"IMAGE/JPEG", true},
+		{"text/plain; charset=utf-8", false},
+		{"application/pdf-x", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := spec.Allows(tt.contentType); got != tt.want {
+			t.Errorf("Allows(%q): expected %v, got %v", tt.contentType, tt.want, got)
+		}
+	}
+}
+
+func TestLoadConfigAttachments(t *testing.T) {
+	testConfig := `bot:
+  discord:
+    token: TEST_TOKEN
+
+commands:
+  - name: upload
+    type: slash
+    webhook: "https://example.com/upload"
+    attachments: forward
+    fields:
+      - name: file
+        type: attachment
+  - name: receipt
+    type: slash
+    webhook: "https://example.com/receipt"
+    attachments:
+      mode: base64
+      max_size: 2097152
+      content_types: ["application/pdf", "image/*"]
+    fields:
+      - name: scan
+        type: attachment`
+
+	tmpFile, err := os.CreateTemp("", "test-config-*.yml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(testConfig); err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	cfg, err := LoadConfig(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if mode := cfg.Commands[0].Attachments.Mode; mode != "forward" {
+		t.Errorf("Expected forward mode, got '%s'", mode)
+	}
+	receipt := cfg.Commands[1].Attachments
+	if receipt.Mode != "base64" || receipt.Limit() != 2<<20 || len(receipt.ContentTypes) != 2 {
+		t.Errorf("Expected base64 block, got %+v", receipt)
+	}
+}
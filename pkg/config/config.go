@@ -1,6 +1,7 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -15,25 +16,72 @@ type Config struct {
 
 type BotConfig struct {
 	Discord DiscordConfig `yaml:"discord"`
+	Webhook WebhookConfig `yaml:"webhook,omitempty"`
+	Outbox  OutboxConfig  `yaml:"outbox,omitempty"`
 }
 
 type DiscordConfig struct {
-	Token string `yaml:"token"`
+	Token  string   `yaml:"token" interpolate:"secret"`
+	Guilds []string `yaml:"guilds,omitempty" interpolate:"env"`
+}
+
+// WebhookConfig holds the defaults for every command's webhook.
+type WebhookConfig struct {
+	Retry *RetryPolicy `yaml:"retry,omitempty"`
+	// LegacyPayload sends the field values as a flat object instead of the
+	// envelope with the interaction context.
+	LegacyPayload bool `yaml:"legacy_payload,omitempty"`
 }
 
 type CommandSpec struct {
 	Name    string      `yaml:"name"`
 	Type    string      `yaml:"type"`
-	Webhook string      `yaml:"webhook"`
-	Fields  []FieldSpec `yaml:"fields"`
+	Webhook WebhookSpec `yaml:"webhook"`
+	// Webhooks sends submissions to several destinations instead of Webhook.
+	Webhooks []WebhookTarget `yaml:"webhooks,omitempty"`
+	Guilds   []string        `yaml:"guilds,omitempty" interpolate:"env"`
+	Access   *AccessSpec     `yaml:"access,omitempty"`
+	Retry    *RetryPolicy    `yaml:"retry,omitempty"`
+	// Secret signs the command's webhook requests; see pkg/signature.
+	Secret string `yaml:"secret,omitempty" interpolate:"secret"`
+	// PayloadTemplate replaces the default webhook body.
+	PayloadTemplate *PayloadTemplate `yaml:"payload_template,omitempty"`
+	// LegacyPayload overrides bot.webhook.legacy_payload.
+	LegacyPayload *bool `yaml:"legacy_payload,omitempty"`
+	// Attachments controls how uploaded files are sent to the webhook.
+	Attachments *AttachmentSpec `yaml:"attachments,omitempty"`
+	// Response customises the messages shown to the user.
+	Response *ResponseSpec `yaml:"response,omitempty"`
+	// Visibility is public (the default) to reply in the channel, or
+	// ephemeral to reply to the submitting user only.
+	Visibility string `yaml:"visibility,omitempty"`
+	// Notice posts a redacted notice of submissions to a channel.
+	Notice *NoticeSpec `yaml:"notice,omitempty"`
+	Fields []FieldSpec `yaml:"fields"`
 }
 
 type FieldSpec struct {
-	Name     string   `yaml:"name"`
-	Type     string   `yaml:"type"`
-	Options  []string `yaml:"options,omitempty"`
-	Webhook  string   `yaml:"webhook,omitempty"`
-	Required bool     `yaml:"required,omitempty"`
+	Name     string      `yaml:"name"`
+	Type     string      `yaml:"type"`
+	Options  []string    `yaml:"options,omitempty"`
+	Webhook  WebhookSpec `yaml:"webhook,omitempty"`
+	Required bool        `yaml:"required,omitempty"`
+
+	// MinValue and MaxValue bound integer and number fields.
+	MinValue *float64 `yaml:"min_value,omitempty"`
+	MaxValue *float64 `yaml:"max_value,omitempty"`
+	// MinLength and MaxLength bound the length of text input.
+	MinLength *int `yaml:"min_length,omitempty"`
+	MaxLength *int `yaml:"max_length,omitempty"`
+	// ChannelTypes restricts which channels a channel field accepts.
+	ChannelTypes []string `yaml:"channel_types,omitempty"`
+	// MinValues and MaxValues bound how many options a select menu in a
+	// modal accepts.
+	MinValues *int `yaml:"min_values,omitempty"`
+	MaxValues *int `yaml:"max_values,omitempty"`
+	// Validate holds the checks applied to text entered in the field. Without
+	// it, checks are guessed from the field name.
+	Validate *ValidationRules `yaml:"validate,omitempty"`
 }
 
 type RemoteOption struct {
@@ -58,6 +106,14 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse YAML config: %w", err)
 	}
 
+	if err := config.Interpolate(); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
 
@@ -68,3 +124,35 @@ func (c *Config) GetCommands() []CommandSpec {
 func (c *Config) GetDiscordToken() string {
 	return c.Bot.Discord.Token
 }
+
+// GuildsFor returns the guild IDs a command is registered in: its own guilds
+// list, or else the default bot.discord.guilds. An empty string stands for
+// global registration, used when neither list is set.
+func (c *Config) GuildsFor(cmd CommandSpec) []string {
+	if len(cmd.Guilds) > 0 {
+		return cmd.Guilds
+	}
+	if len(c.Bot.Discord.Guilds) > 0 {
+		return c.Bot.Discord.Guilds
+	}
+	return []string{""}
+}
+
+// LegacyPayloadFor reports whether a command's webhook gets the flat field
+// values rather than the envelope: the command's legacy_payload if set,
+// otherwise bot.webhook.legacy_payload.
+func (c *Config) LegacyPayloadFor(cmd CommandSpec) bool {
+	if cmd.LegacyPayload != nil {
+		return *cmd.LegacyPayload
+	}
+	return c.Bot.Webhook.LegacyPayload
+}
+
+// SameSettings reports whether two commands are configured alike. Unlike
+// reflect.DeepEqual it ignores what validation derives from the settings,
+// such as parsed templates.
+func (c CommandSpec) SameSettings(other CommandSpec) bool {
+	a, errA := yaml.Marshal(c)
+	b, errB := yaml.Marshal(other)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
@@ -54,8 +54,8 @@ commands:
 		t.Errorf("Expected command type 'slash', got '%s'", cmd.Type)
 	}
 
-	if cmd.Webhook != "https://example.com/webhook" {
-		t.Errorf("Expected webhook 'https://example.com/webhook', got '%s'", cmd.Webhook)
+	if cmd.Webhook.URL != "https://example.com/webhook" {
+		t.Errorf("Expected webhook 'https://example.com/webhook', got '%s'", cmd.Webhook.URL)
 	}
 
 	if len(cmd.Fields) != 2 {
@@ -132,3 +132,47 @@ func TestLoadConfigInvalidYAML(t *testing.T) {
 		t.Error("Expected error for invalid YAML, got nil")
 	}
 }
+
+func TestGuildsFor(t *testing.T) {
+	cfg := &Config{
+		Bot: BotConfig{
+			Discord: DiscordConfig{Guilds: []string{"111111111111111111"}},
+		},
+	}
+
+	own := cfg.GuildsFor(CommandSpec{Name: "ops", Guilds: []string{"222222222222222222"}})
+	if len(own) != 1 || own[0] != "222222222222222222" {
+		t.Errorf("Expected command guilds, got %v", own)
+	}
+
+	defaults := cfg.GuildsFor(CommandSpec{Name: "report"})
+	if len(defaults) != 1 || defaults[0] != "111111111111111111" {
+		t.Errorf("Expected default guilds, got %v", defaults)
+	}
+
+	cfg.Bot.Discord.Guilds = nil
+	global := cfg.GuildsFor(CommandSpec{Name: "report"})
+	if len(global) != 1 || global[0] != "" {
+		t.Errorf("Expected global scope, got %v", global)
+	}
+}
+
+func TestLegacyPayloadFor(t *testing.T) {
+	cfg := &Config{}
+	enabled, disabled := true, false
+
+	if cfg.LegacyPayloadFor(CommandSpec{Name: "report"}) {
+		t.Error("Expected the envelope by default")
+	}
+	if !cfg.LegacyPayloadFor(CommandSpec{Name: "report", LegacyPayload: &enabled}) {
+		t.Error("Expected command legacy_payload to enable the flat format")
+	}
+
+	cfg.Bot.Webhook.LegacyPayload = true
+	if !cfg.LegacyPayloadFor(CommandSpec{Name: "report"}) {
+		t.Error("Expected bot.webhook.legacy_payload to apply to every command")
+	}
+	if cfg.LegacyPayloadFor(CommandSpec{Name: "report", LegacyPayload: &disabled}) {
+		t.Error("Expected command legacy_payload to override the global setting")
+	}
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const secretFilePrefix = "file:"
+
+// Interpolation modes, set on a field with an interpolate struct tag. Fields
+// nested in a tagged field, such as the entries of headers or auth, use the
+// same mode. Strings in untagged fields are left as written, so templates,
+// patterns and labels may contain $ and file: freely.
+const (
+	// interpolateEnv expands environment variables.
+	interpolateEnv = "env"
+	// interpolateSecret also replaces a value of the form "file:/path" with
+	// the contents of the file.
+	interpolateSecret = "secret"
+)
+
+// envPattern matches $$ (an escaped dollar sign), ${VAR} and ${VAR:-default}.
+var envPattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// Interpolate expands environment variables and secret files in the fields
+// tagged for it: the bot token, guild IDs, webhook URLs, headers and auth,
+// signing secrets and the outbox directory. In those fields ${VAR} and
+// ${VAR:-default} are replaced by the value of the environment variable, and
+// in the secret-bearing ones (token, headers, auth and secret) a value of the
+// form "file:/path" is replaced by the contents of that file. Unset variables
+// without a default are reported as problems.
+func (c *Config) Interpolate() error {
+	verr := &ValidationError{}
+	interpolateValue(verr, "", reflect.ValueOf(c).Elem(), "")
+
+	if len(verr.Problems) > 0 {
+		return verr
+	}
+	return nil
+}
+
+func interpolateValue(verr *ValidationError, path string, v reflect.Value, mode string) {
+	switch v.Kind() {
+	case reflect.String:
+		if mode == "" {
+			return
+		}
+		expanded, err := expandString(v.String(), mode == interpolateSecret)
+		if err != nil {
+			verr.add(path, "%v", err)
+			return
+		}
+		v.SetString(expanded)
+	case reflect.Ptr:
+		if !v.IsNil() {
+			interpolateValue(verr, path, v.Elem(), mode)
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			// The value in an interface is not addressable, so expand a copy.
+			elem := reflect.New(v.Elem().Type()).Elem()
+			elem.Set(v.Elem())
+			interpolateValue(verr, path, elem, mode)
+			v.Set(elem)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			fieldPath := joinPath(path, yamlName(t.Field(i)))
+			if isInline(t.Field(i)) {
+				fieldPath = path
+			}
+			fieldMode := mode
+			if tag := t.Field(i).Tag.Get("interpolate"); tag != "" {
+				fieldMode = tag
+			}
+			interpolateValue(verr, fieldPath, v.Field(i), fieldMode)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			interpolateValue(verr, fmt.Sprintf("%s[%d]", path, i), v.Index(i), mode)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// Map values are not addressable, so expand a copy and store it back.
+			elem := reflect.New(iter.Value().Type()).Elem()
+			elem.Set(iter.Value())
+			interpolateValue(verr, joinPath(path, fmt.Sprint(iter.Key().Interface())), elem, mode)
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	}
+}
+
+// expandString expands the environment variables in s. When secretFile is
+// set and s is a secret reference, i.e. starts with "file:", it returns the
+// contents of the file at the expanded path instead.
+func expandString(s string, secretFile bool) (string, error) {
+	isFile := secretFile && strings.HasPrefix(s, secretFilePrefix)
+	if isFile {
+		s = strings.TrimPrefix(s, secretFilePrefix)
+	}
+
+	var missing []string
+	expanded := envPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$$" {
+			return "$"
+		}
+		groups := envPattern.FindStringSubmatch(match)
+		value, ok := os.LookupEnv(groups[1])
+		if groups[2] != "" && value == "" {
+			return groups[3]
+		}
+		if !ok {
+			missing = append(missing, groups[1])
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+
+	if isFile {
+		data, err := os.ReadFile(expanded)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	return expanded, nil
+}
+
+func yamlName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+	if name == "" || name == "-" {
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
+
+// isInline reports whether a field's YAML keys are part of the enclosing
+// mapping.
+func isInline(f reflect.StructField) bool {
+	return slices.Contains(strings.Split(f.Tag.Get("yaml"), ",")[1:], "inline")
+}
+
+func joinPath(base, name string) string {
+	if base == "" {
+		return name
+	}
+	return base + "." + name
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("YAMBOT_TEST_TOKEN", "secret-token")
+	t.Setenv("YAMBOT_TEST_HOST", "n8n.example.com")
+	t.Setenv("YAMBOT_TEST_EMPTY", "")
+
+	secretFile := filepath.Join(t.TempDir(), "webhook")
+	if err := os.WriteFile(secretFile, []byte("https://secret.example.com/hook\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write secret file: %v", err)
+	}
+
+	cfg := &Config{
+		Bot: BotConfig{Discord: DiscordConfig{Token: "${YAMBOT_TEST_TOKEN}"}},
+		Commands: []CommandSpec{
+			{
+				Name:    "report",
+				Webhook: WebhookSpec{URL: "https://${YAMBOT_TEST_HOST}/webhook/${YAMBOT_TEST_PATH:-report}"},
+				Fields: []FieldSpec{
+					{Name: "team", Webhook: WebhookSpec{URL: "https://example.com/teams", Auth: &AuthSpec{Type: "bearer", Token: "file:" + secretFile}}},
+					{Name: "price", Webhook: WebhookSpec{URL: "https://example.com/prices?currency=$$", Headers: map[string]string{"X-Plan": "${YAMBOT_TEST_EMPTY:-free}"}}},
+				},
+			},
+		},
+	}
+
+	if err := cfg.Interpolate(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if cfg.Bot.Discord.Token != "secret-token" {
+		t.Errorf("Expected token 'secret-token', got '%s'", cfg.Bot.Discord.Token)
+	}
+	if cfg.Commands[0].Webhook.URL != "https://n8n.example.com/webhook/report" {
+		t.Errorf("Expected expanded command webhook, got '%s'", cfg.Commands[0].Webhook.URL)
+	}
+	if cfg.Commands[0].Fields[0].Webhook.Auth.Token != "https://secret.example.com/hook" {
+		t.Errorf("Expected auth token from secret file, got '%s'", cfg.Commands[0].Fields[0].Webhook.Auth.Token)
+	}
+	if cfg.Commands[0].Fields[1].Webhook.URL != "https://example.com/prices?currency=$" {
+		t.Errorf("Expected escaped dollar sign, got '%s'", cfg.Commands[0].Fields[1].Webhook.URL)
+	}
+	if cfg.Commands[0].Fields[1].Webhook.Headers["X-Plan"] != "free" {
+		t.Errorf("Expected default for empty variable, got '%s'", cfg.Commands[0].Fields[1].Webhook.Headers["X-Plan"])
+	}
+}
+
+func TestInterpolate_UntaggedFields(t *testing.T) {
+	t.Setenv("YAMBOT_TEST_HOST", "n8n.example.com")
+
+	cfg := &Config{
+		Commands: []CommandSpec{
+			{
+				Name:            "report",
+				Webhook:         WebhookSpec{URL: "file:/run/secrets/report_url"},
+				PayloadTemplate: &PayloadTemplate{Text: "${YAMBOT_TEST_HOST}"},
+				Fields: []FieldSpec{
+					{Name: "kind", Options: []string{"file:report", "$$5"}},
+					{Name: "amount", Validate: &ValidationRules{Pattern: `^\d+$$`}},
+				},
+			},
+		},
+	}
+
+	if err := cfg.Interpolate(); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if cfg.Commands[0].Webhook.URL != "file:/run/secrets/report_url" {
+		t.Errorf("Expected webhook URL not to be read as a secret file, got '%s'", cfg.Commands[0].Webhook.URL)
+	}
+	if cfg.Commands[0].PayloadTemplate.Text != "${YAMBOT_TEST_HOST}" {
+		t.Errorf("Expected payload template to be left as written, got '%s'", cfg.Commands[0].PayloadTemplate.Text)
+	}
+	if options := cfg.Commands[0].Fields[0].Options; options[0] != "file:report" || options[1] != "$$5" {
+		t.Errorf("Expected options to be left as written, got %v", options)
+	}
+	if pattern := cfg.Commands[0].Fields[1].Validate.Pattern; pattern != `^\d+$$` {
+		t.Errorf("Expected pattern to be left as written, got '%s'", pattern)
+	}
+}
+
+func TestInterpolate_Errors(t *testing.T) {
+	cfg := &Config{
+		Bot: BotConfig{Discord: DiscordConfig{Token: "${YAMBOT_TEST_UNSET_TOKEN}"}},
+		Commands: []CommandSpec{
+			{Name: "report", Secret: "file:/nonexistent/yambot-secret"},
+		},
+	}
+
+	err := cfg.Interpolate()
+	if err == nil {
+		t.Fatal("Expected interpolation error, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "bot.discord.token") || !strings.Contains(err.Error(), "YAMBOT_TEST_UNSET_TOKEN") {
+		t.Errorf("Expected error for unset token variable, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "commands[0].secret") {
+		t.Errorf("Expected error for missing secret file, got: %v", err)
+	}
+}
+
+func TestLoadConfigInterpolatesToken(t *testing.T) {
+	t.Setenv("YAMBOT_TEST_TOKEN", "from-env")
+
+	testConfig := `bot:
+  discord:
+    token: ${YAMBOT_TEST_TOKEN}`
+
+	tmpFile, err := os.CreateTemp("", "env-config-*.yml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(testConfig); err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	cfg, err := LoadConfig(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.GetDiscordToken() != "from-env" {
+		t.Errorf("Expected token 'from-env', got '%s'", cfg.GetDiscordToken())
+	}
+}
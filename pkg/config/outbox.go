@@ -0,0 +1,38 @@
+package config
+
+import "time"
+
+const (
+	defaultOutboxInterval    = 30 * time.Second
+	defaultOutboxMaxAttempts = 20
+)
+
+// OutboxConfig enables the on-disk outbox, which keeps webhook payloads until
+// they are delivered.
+type OutboxConfig struct {
+	// Dir is the directory holding the outbox database. The outbox is
+	// disabled when it is empty.
+	Dir string `yaml:"dir,omitempty" interpolate:"env"`
+	// Interval is how often undelivered payloads are retried in the
+	// background; the wait doubles after every failed attempt.
+	Interval time.Duration `yaml:"interval,omitempty"`
+	// MaxAttempts is the number of attempts after which a payload is moved to
+	// the dead-letter list.
+	MaxAttempts int `yaml:"max_attempts,omitempty"`
+}
+
+// Enabled reports whether an outbox directory is configured.
+func (o OutboxConfig) Enabled() bool {
+	return o.Dir != ""
+}
+
+// WithDefaults returns o with unset values replaced by their defaults.
+func (o OutboxConfig) WithDefaults() OutboxConfig {
+	if o.Interval == 0 {
+		o.Interval = defaultOutboxInterval
+	}
+	if o.MaxAttempts == 0 {
+		o.MaxAttempts = defaultOutboxMaxAttempts
+	}
+	return o
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// ResponseStyles lists the names accepted in a response block's style.
+var ResponseStyles = []string{"text", "embed"}
+
+// AvatarThumbnail is the embed thumbnail that shows the submitter's avatar.
+const AvatarThumbnail = "avatar"
+
+// ResponseSpec customises the messages a command replies with. Success,
+// Failure and Validation are text/template strings executed with a
+// ResponseData; unset messages keep the defaults.
+type ResponseSpec struct {
+	// Success replaces the confirmation shown after a submission.
+	Success string `yaml:"success,omitempty"`
+	// Failure replaces the message shown when a required webhook
+	// destination failed.
+	Failure string `yaml:"failure,omitempty"`
+	// Validation replaces the message shown for input that failed
+	// validation.
+	Validation string `yaml:"validation,omitempty"`
+	// HideFields leaves the submitted values out of the default messages.
+	HideFields bool `yaml:"hide_fields,omitempty"`
+	// HideEndpoint leaves webhook URLs out of the default messages.
+	HideEndpoint bool `yaml:"hide_endpoint,omitempty"`
+	// Style is text (the default) for a markdown message, or embed to show
+	// the submission as a Discord embed.
+	Style string `yaml:"style,omitempty"`
+	// Embed sets the look of embed responses.
+	Embed *EmbedSpec `yaml:"embed,omitempty"`
+
+	// parsed holds the templates by the name passed to Render, filled in
+	// when the config is validated.
+	parsed map[string]*template.Template
+}
+
+// EmbedSpec sets the look of embed responses.
+type EmbedSpec struct {
+	// Color is a hex colour such as "#5865F2". Without it, the colour
+	// follows the outcome of the submission.
+	Color string `yaml:"color,omitempty"`
+	// Title is a template executed with a ResponseData; it defaults to the
+	// command name.
+	Title string `yaml:"title,omitempty"`
+	// Thumbnail is an image URL, or "avatar" for the submitter's avatar.
+	Thumbnail string `yaml:"thumbnail,omitempty"`
+}
+
+// UsesEmbed reports whether responses are shown as embeds.
+func (r *ResponseSpec) UsesEmbed() bool {
+	return r != nil && r.Style == "embed"
+}
+
+// ColorValue returns the configured colour as a number, and false when none
+// is set or it is not a valid hex colour.
+func (e *EmbedSpec) ColorValue() (int, bool) {
+	if e == nil || e.Color == "" {
+		return 0, false
+	}
+	hex := strings.TrimPrefix(e.Color, "#")
+	if len(hex) != 6 {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int(value), true
+}
+
+// HidesFields reports whether submitted values are left out of the default
+// messages.
+func (r *ResponseSpec) HidesFields() bool {
+	return r != nil && r.HideFields
+}
+
+// HidesEndpoint reports whether webhook URLs are left out of the default
+// messages.
+func (r *ResponseSpec) HidesEndpoint() bool {
+	return r != nil && r.HideEndpoint
+}
+
+// ResponseData describes a submission and its delivery. It is what response
+// templates are executed with.
+type ResponseData struct {
+	Command   string
+	User      PayloadUser
+	GuildID   string
+	ChannelID string
+	// Fields holds the submitted values as shown to the user, by field name.
+	Fields   map[string]string
+	Delivery DeliveryData
+	// Error lists the problems with the input, for validation messages.
+	Error string
+}
+
+// DeliveryData is the outcome of sending a submission to its webhooks.
+type DeliveryData struct {
+	// Status is sent, queued (some destinations will be retried later),
+	// partial (an optional destination failed) or failed, and empty for
+	// commands without a webhook.
+	Status string
+	// Attempts is the total number of requests made.
+	Attempts int
+	// Error is the error of the first failed destination.
+	Error        string
+	Destinations []DestinationData
+}
+
+// DestinationData is the outcome of sending a submission to one destination.
+type DestinationData struct {
+	// Name is empty for destinations without a name.
+	Name     string
+	Required bool
+	// Status is sent, queued or failed.
+	Status   string
+	Attempts int
+	Error    string
+}
+
+// sampleResponseData is what response and notice templates are test-rendered
+// with when the config is validated: a submission of cmd with every field
+// left empty.
+func sampleResponseData(cmd CommandSpec) ResponseData {
+	data := ResponseData{
+		Command: cmd.Name,
+		User:    PayloadUser{Roles: []string{}},
+		Fields:  make(map[string]string, len(cmd.Fields)),
+	}
+	for _, field := range cmd.Fields {
+		data.Fields[field.Name] = ""
+	}
+	return data
+}
+
+// Render executes the response template called name (success, failure,
+// validation or embed.title) with data.
+func (r *ResponseSpec) Render(name string, data ResponseData) (string, error) {
+	tmpl := r.parsed[name]
+	if tmpl == nil {
+		var err error
+		if tmpl, err = parseTemplate("response."+name, r.templateText(name)); err != nil {
+			return "", err
+		}
+	}
+	return execute(tmpl, data)
+}
+
+// templateText returns the text of the response template called name.
+func (r *ResponseSpec) templateText(name string) string {
+	switch name {
+	case "success":
+		return r.Success
+	case "failure":
+		return r.Failure
+	case "validation":
+		return r.Validation
+	case "embed.title":
+		if r.Embed != nil {
+			return r.Embed.Title
+		}
+	}
+	return ""
+}
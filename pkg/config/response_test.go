@@ -0,0 +1,121 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestResponseSpec_Render(t *testing.T) {
+	data := ResponseData{
+		Command: "ticket",
+		User:    PayloadUser{DisplayName: "Jane"},
+		Fields:  map[string]string{"title": "Printer on fire", "notes": ""},
+		Delivery: DeliveryData{
+			Status:   "sent",
+			Attempts: 2,
+		},
+	}
+
+	text := `Thanks {{ .User.DisplayName }}, "{{ .Fields.title }}" was {{ .Delivery.Status }}{{ if .Fields.notes }} with notes{{ end }}.`
+	got, err := (&ResponseSpec{Success: text}).Render("success", data)
+	if err != nil {
+		t.Fatalf("Expected template to render, got %v", err)
+	}
+	if want := `Thanks Jane, "Printer on fire" was sent.`; got != want {
+		t.Errorf("Expected '%s', got '%s'", want, got)
+	}
+
+	if _, err := (&ResponseSpec{Success: "{{ .Missing.Field }}"}).Render("success", data); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+}
+
+func TestResponseSpec_Hides(t *testing.T) {
+	var unset *ResponseSpec
+	if unset.HidesFields() || unset.HidesEndpoint() {
+		t.Error("Expected no response block to show fields and endpoint")
+	}
+
+	spec := &ResponseSpec{HideFields: true, HideEndpoint: true}
+	if !spec.HidesFields() || !spec.HidesEndpoint() {
+		t.Errorf("Expected fields and endpoint hidden, got %+v", spec)
+	}
+}
+
+func TestEmbedSpec_ColorValue(t *testing.T) {
+	tests := []struct {
+		color string
+		want  int
+		ok    bool
+	}{
+		{"#5865F2", 0x5865F2, true},
+		{"57f287", 0x57F287, true},
+		{"", 0, false},
+		{"#FFF", 0, false},
+		{"#GGGGGG", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := (&EmbedSpec{Color: tt.color}).ColorValue()
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ColorValue(%q): expected %#x, %v, got %#x, %v", tt.color, tt.want, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestLoadConfigResponse(t *testing.T) {
+	testConfig := `bot:
+  discord:
+    token: TEST_TOKEN
+
+commands:
+  - name: ticket
+    type: modal
+    webhook: "https://example.com/tickets"
+    response:
+      success: "Thanks {{ .User.DisplayName }}, your ticket was filed."
+      failure: "Sorry, we could not file your ticket: {{ .Delivery.Error }}"
+      validation: "{{ .Error }}"
+      hide_fields: true
+      hide_endpoint: true
+      style: embed
+      embed:
+        color: "#5865F2"
+        title: "Ticket from {{ .User.DisplayName }}"
+        thumbnail: avatar
+    fields:
+      - name: title
+        type: text`
+
+	tmpFile, err := os.CreateTemp("", "test-config-*.yml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(testConfig); err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	cfg, err := LoadConfig(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	response := cfg.Commands[0].Response
+	if response == nil {
+		t.Fatal("Expected a response block")
+	}
+	if response.Success != "Thanks {{ .User.DisplayName }}, your ticket was filed." {
+		t.Errorf("Unexpected success template '%s'", response.Success)
+	}
+	if response.Failure == "" || response.Validation != "{{ .Error }}" {
+		t.Errorf("Expected failure and validation templates, got %+v", response)
+	}
+	if !response.HideFields || !response.HideEndpoint {
+		t.Errorf("Expected fields and endpoint hidden, got %+v", response)
+	}
+	if !response.UsesEmbed() || response.Embed == nil || response.Embed.Thumbnail != AvatarThumbnail {
+		t.Errorf("Expected embed style with the avatar thumbnail, got %+v", response)
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"slices"
+	"time"
+)
+
+// RetryPolicy controls how often a failed webhook delivery is retried. Unset
+// fields fall back to the global bot.webhook.retry policy, then to
+// DefaultRetryPolicy.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries, including the first one.
+	MaxAttempts int `yaml:"max_attempts,omitempty"`
+	// BaseDelay is the wait before the first retry; it doubles with every
+	// further retry, up to MaxDelay.
+	BaseDelay time.Duration `yaml:"base_delay,omitempty"`
+	MaxDelay  time.Duration `yaml:"max_delay,omitempty"`
+	// RetryOn lists the HTTP status codes worth retrying. Network errors are
+	// always retried.
+	RetryOn []int `yaml:"retry_on,omitempty"`
+}
+
+// DefaultRetryPolicy tries a webhook once. Setting max_attempts enables
+// retries with the delays and status codes below.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 1,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+	RetryOn:     []int{408, 425, 429, 500, 502, 503, 504},
+}
+
+// Retryable reports whether a response with the given status code should be
+// retried.
+func (p RetryPolicy) Retryable(status int) bool {
+	return slices.Contains(p.RetryOn, status)
+}
+
+// Window returns the longest a delivery with the policy can take before it
+// gives up: every attempt running into the timeout, with the longest allowed
+// wait between them.
+func (p RetryPolicy) Window(timeout time.Duration) time.Duration {
+	attempts := max(p.MaxAttempts, 1)
+	return time.Duration(attempts)*timeout + time.Duration(attempts-1)*p.MaxDelay
+}
+
+// merge returns p with the fields set in override replaced.
+func (p RetryPolicy) merge(override *RetryPolicy) RetryPolicy {
+	if override == nil {
+		return p
+	}
+	if override.MaxAttempts != 0 {
+		p.MaxAttempts = override.MaxAttempts
+	}
+	if override.BaseDelay != 0 {
+		p.BaseDelay = override.BaseDelay
+	}
+	if override.MaxDelay != 0 {
+		p.MaxDelay = override.MaxDelay
+	}
+	if override.RetryOn != nil {
+		p.RetryOn = override.RetryOn
+	}
+	return p
+}
+
+// RetryFor returns the retry policy for a command's webhook: the command's own
+// retry settings over the global ones over DefaultRetryPolicy.
+func (c *Config) RetryFor(cmd CommandSpec) RetryPolicy {
+	return DefaultRetryPolicy.merge(c.Bot.Webhook.Retry).merge(cmd.Retry)
+}
+
+// RetryForTarget returns the retry policy for one of a command's
+// destinations: its own retry settings over the command's.
+func (c *Config) RetryForTarget(cmd CommandSpec, target WebhookTarget) RetryPolicy {
+	return c.RetryFor(cmd).merge(target.Retry)
+}
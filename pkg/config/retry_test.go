@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRetryFor(t *testing.T) {
+	cfg := &Config{
+		Bot: BotConfig{Webhook: WebhookConfig{Retry: &RetryPolicy{MaxAttempts: 3, MaxDelay: 5 * time.Second}}},
+	}
+
+	policy := cfg.RetryFor(CommandSpec{Name: "plain"})
+	if policy.MaxAttempts != 3 || policy.MaxDelay != 5*time.Second {
+		t.Errorf("Expected global policy, got %+v", policy)
+	}
+	if policy.BaseDelay != DefaultRetryPolicy.BaseDelay || !slices.Equal(policy.RetryOn, DefaultRetryPolicy.RetryOn) {
+		t.Errorf("Expected defaults for unset fields, got %+v", policy)
+	}
+
+	policy = cfg.RetryFor(CommandSpec{Name: "custom", Retry: &RetryPolicy{MaxAttempts: 5, RetryOn: []int{503}}})
+	if policy.MaxAttempts != 5 || policy.MaxDelay != 5*time.Second || !slices.Equal(policy.RetryOn, []int{503}) {
+		t.Errorf("Expected command policy over global policy, got %+v", policy)
+	}
+
+	if policy := (&Config{}).RetryFor(CommandSpec{}); policy.MaxAttempts != 1 {
+		t.Errorf("Expected a single attempt by default, got %d", policy.MaxAttempts)
+	}
+}
+
+func TestLoadConfigRetry(t *testing.T) {
+	testConfig := `bot:
+  discord:
+    token: TEST_TOKEN
+  webhook:
+    retry:
+      max_attempts: 4
+      base_delay: 250ms
+      max_delay: 8s
+      retry_on: [429, 503]
+
+commands:
+  - name: report
+    type: slash
+    webhook: "https://example.com/webhook"
+    retry:
+      max_attempts: 2
+    fields:
+      - name: title
+        type: text`
+
+	tmpFile, err := os.CreateTemp("", "test-config-*.yml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(testConfig); err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	cfg, err := LoadConfig(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	policy := cfg.RetryFor(cfg.Commands[0])
+	if policy.MaxAttempts != 2 || policy.BaseDelay != 250*time.Millisecond || policy.MaxDelay != 8*time.Second {
+		t.Errorf("Expected merged retry policy, got %+v", policy)
+	}
+	if !policy.Retryable(503) || policy.Retryable(500) {
+		t.Errorf("Expected only 429 and 503 to be retryable, got %v", policy.RetryOn)
+	}
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidationRules are checks applied to the text a user enters in a field,
+// configured in the field's validate block.
+type ValidationRules struct {
+	// Format is one of the names in Formats.
+	Format string `yaml:"format,omitempty"`
+	// Pattern is a regular expression the whole value must match.
+	Pattern   string `yaml:"pattern,omitempty"`
+	MinLength *int   `yaml:"min_length,omitempty"`
+	MaxLength *int   `yaml:"max_length,omitempty"`
+	// Min and Max bound the value of number and integer formats.
+	Min *float64 `yaml:"min,omitempty"`
+	Max *float64 `yaml:"max,omitempty"`
+	// Message replaces the default error shown when a check fails.
+	Message string `yaml:"message,omitempty"`
+
+	// pattern is Pattern compiled, filled in when the config is validated.
+	pattern *regexp.Regexp
+}
+
+// Formats lists the names accepted in a validate block's format.
+var Formats = []string{"email", "url", "phone", "number", "integer", "date", "uuid"}
+
+var (
+	emailPattern = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+	urlPattern   = regexp.MustCompile(`^https?://[^\s]+$`)
+	phonePattern = regexp.MustCompile(`^[\+]?[1-9][\d]{0,15}$`)
+	uuidPattern  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// dateLayout is the layout of the date format.
+const dateLayout = "2006-01-02"
+
+// Check reports whether value satisfies the rules. The returned error reads
+// as the end of a sentence about the field, such as "must be a valid email
+// address", unless a custom Message is set.
+func (r *ValidationRules) Check(value string) error {
+	if err := r.check(value); err != nil {
+		if r.Message != "" {
+			return fmt.Errorf("%s", r.Message)
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *ValidationRules) check(value string) error {
+	length := utf8.RuneCountInString(value)
+	if r.MinLength != nil && length < *r.MinLength {
+		return fmt.Errorf("must be at least %d characters long", *r.MinLength)
+	}
+	if r.MaxLength != nil && length > *r.MaxLength {
+		return fmt.Errorf("must be at most %d characters long", *r.MaxLength)
+	}
+
+	switch r.Format {
+	case "email":
+		if !emailPattern.MatchString(value) {
+			return fmt.Errorf("must be a valid email address")
+		}
+	case "url":
+		if !urlPattern.MatchString(value) {
+			return fmt.Errorf("must be a valid URL starting with http:// or https://")
+		}
+	case "phone":
+		if !phonePattern.MatchString(strings.ReplaceAll(value, " ", "")) {
+			return fmt.Errorf("must be a valid phone number")
+		}
+	case "number":
+		number, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(value), ",", "."), 64)
+		if err != nil {
+			return fmt.Errorf("must be a valid number")
+		}
+		if err := r.checkRange(number); err != nil {
+			return err
+		}
+	case "integer":
+		number, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("must be a whole number")
+		}
+		if err := r.checkRange(float64(number)); err != nil {
+			return err
+		}
+	case "date":
+		if _, err := time.Parse(dateLayout, strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("must be a date in the form YYYY-MM-DD")
+		}
+	case "uuid":
+		if !uuidPattern.MatchString(strings.TrimSpace(value)) {
+			return fmt.Errorf("must be a valid UUID")
+		}
+	}
+
+	if r.Pattern != "" {
+		pattern, err := r.compiledPattern()
+		if err != nil {
+			return fmt.Errorf("cannot be checked (invalid pattern)")
+		}
+		if !pattern.MatchString(value) {
+			return fmt.Errorf("has an invalid format")
+		}
+	}
+
+	return nil
+}
+
+func (r *ValidationRules) checkRange(number float64) error {
+	if r.Min != nil && number < *r.Min {
+		return fmt.Errorf("must be at least %s", strconv.FormatFloat(*r.Min, 'f', -1, 64))
+	}
+	if r.Max != nil && number > *r.Max {
+		return fmt.Errorf("must be at most %s", strconv.FormatFloat(*r.Max, 'f', -1, 64))
+	}
+	return nil
+}
+
+// compiledPattern returns the compiled Pattern. Rules that were not validated
+// have it compiled on every call.
+func (r *ValidationRules) compiledPattern() (*regexp.Regexp, error) {
+	if r.pattern != nil {
+		return r.pattern, nil
+	}
+	return regexp.Compile(anchorPattern(r.Pattern))
+}
+
+// anchorPattern makes a pattern match the whole value rather than a part of it.
+func anchorPattern(pattern string) string {
+	return `^(?:` + pattern + `)$`
+}
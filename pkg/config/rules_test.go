@@ -0,0 +1,74 @@
+package config
+
+import "testing"
+
+func TestValidationRulesCheck(t *testing.T) {
+	minLength, maxLength := 3, 5
+	minValue, maxValue := 1.0, 10.0
+
+	tests := []struct {
+		name      string
+		rules     ValidationRules
+		value     string
+		shouldErr bool
+	}{
+		{"valid email", ValidationRules{Format: "email"}, "test@example.com", false},
+		{"invalid email", ValidationRules{Format: "email"}, "test@", true},
+		{"valid url", ValidationRules{Format: "url"}, "https://example.com", false},
+		{"invalid url", ValidationRules{Format: "url"}, "example.com", true},
+		{"valid phone", ValidationRules{Format: "phone"}, "+49 151 234567", false},
+		{"invalid phone", ValidationRules{Format: "phone"}, "call me", true},
+		{"valid number with comma", ValidationRules{Format: "number"}, "12,50", false},
+		{"invalid number", ValidationRules{Format: "number"}, "twelve", true},
+		{"number below min", ValidationRules{Format: "number", Min: &minValue}, "0.5", true},
+		{"integer in range", ValidationRules{Format: "integer", Min: &minValue, Max: &maxValue}, "7", false},
+		{"integer above max", ValidationRules{Format: "integer", Max: &maxValue}, "11", true},
+		{"fractional integer", ValidationRules{Format: "integer"}, "1.5", true},
+		{"valid date", ValidationRules{Format: "date"}, "2024-02-29", false},
+		{"invalid date", ValidationRules{Format: "date"}, "2023-02-29", true},
+		{"valid uuid", ValidationRules{Format: "uuid"}, "123e4567-e89b-12d3-a456-426614174000", false},
+		{"invalid uuid", ValidationRules{Format: "uuid"}, "123e4567", true},
+		{"pattern match", ValidationRules{Pattern: `[A-Z]{3}-\d+`}, "ABC-123", false},
+		{"pattern must match whole value", ValidationRules{Pattern: `[A-Z]{3}-\d+`}, "xABC-123", true},
+		{"too short", ValidationRules{MinLength: &minLength}, "ab", true},
+		{"too long", ValidationRules{MaxLength: &maxLength}, "abcdef", true},
+		{"length in range", ValidationRules{MinLength: &minLength, MaxLength: &maxLength}, "abcd", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rules.Check(tt.value)
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("Check(%q) error = %v, shouldErr %v", tt.value, err, tt.shouldErr)
+			}
+		})
+	}
+}
+
+func TestValidationRulesCheck_Message(t *testing.T) {
+	rules := ValidationRules{Format: "email", Message: "Please use your work email"}
+
+	err := rules.Check("nope")
+	if err == nil || err.Error() != "Please use your work email" {
+		t.Errorf("Expected custom message, got %v", err)
+	}
+}
+
+func TestValidate_CompilesPattern(t *testing.T) {
+	cfg := validConfig()
+	rules := &ValidationRules{Pattern: `[A-Z]{3}-\d+`}
+	cfg.Commands[0].Fields[0].Validate = rules
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected valid config, got: %v", err)
+	}
+	if rules.pattern == nil {
+		t.Fatal("Expected the pattern to be compiled during validation")
+	}
+	if err := rules.Check("ABC-123"); err != nil {
+		t.Errorf("Expected the compiled pattern to match, got %v", err)
+	}
+	if err := rules.Check("xABC-123"); err == nil {
+		t.Error("Expected the compiled pattern to match the whole value")
+	}
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PayloadTemplate shapes the body of a command's webhook request. In YAML it
+// is either a text/template string producing the whole JSON body, or a JSON
+// template: a mapping or list whose string values are templates, encoded as
+// JSON after rendering.
+type PayloadTemplate struct {
+	Text string
+	JSON interface{}
+
+	// parsed is Text as a template, or JSON with every string parsed as a
+	// template, filled in when the config is validated.
+	parsed interface{}
+}
+
+// PayloadData describes a submission. It is what payload templates are
+// executed with, and encoded as JSON it is the default webhook body.
+type PayloadData struct {
+	InteractionID string `json:"interaction_id"`
+	Command       string `json:"command"`
+	// CommandType is slash or modal.
+	CommandType string      `json:"command_type"`
+	User        PayloadUser `json:"user"`
+	// GuildID is empty for commands run in direct messages.
+	GuildID   string `json:"guild_id"`
+	ChannelID string `json:"channel_id"`
+	Locale    string `json:"locale"`
+	// Timestamp is the submission time in RFC 3339 format.
+	Timestamp string                 `json:"timestamp"`
+	Fields    map[string]interface{} `json:"fields"`
+}
+
+// PayloadUser describes the user who ran a command.
+type PayloadUser struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	// Roles holds the IDs of the member's roles; it is empty outside guilds.
+	Roles []string `json:"roles"`
+}
+
+// samplePayloadData is what payload templates are test-rendered with when
+// the config is validated: a submission of cmd with every field left empty.
+func samplePayloadData(cmd CommandSpec) PayloadData {
+	data := PayloadData{
+		Command:     cmd.Name,
+		CommandType: cmd.Type,
+		User:        PayloadUser{Roles: []string{}},
+		Fields:      make(map[string]interface{}, len(cmd.Fields)),
+	}
+	for _, field := range cmd.Fields {
+		data.Fields[field.Name] = ""
+	}
+	return data
+}
+
+// templateFuncs are the functions available in payload and response templates
+// besides the text/template builtins.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"default": func(def, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+	"join": func(sep string, v interface{}) string {
+		switch values := v.(type) {
+		case []string:
+			return strings.Join(values, sep)
+		case []interface{}:
+			parts := make([]string, len(values))
+			for i, value := range values {
+				parts[i] = fmt.Sprint(value)
+			}
+			return strings.Join(parts, sep)
+		case nil:
+			return ""
+		default:
+			return fmt.Sprint(values)
+		}
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+}
+
+// UnmarshalYAML accepts either a template string or a JSON template.
+func (p *PayloadTemplate) UnmarshalYAML(node *yaml.Node) error {
+	*p = PayloadTemplate{}
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&p.Text)
+	}
+	return node.Decode(&p.JSON)
+}
+
+// Render executes the template and returns the JSON body to send.
+func (p *PayloadTemplate) Render(data PayloadData) ([]byte, error) {
+	parsed := p.parsed
+	if parsed == nil {
+		var err error
+		if parsed, err = p.parse(); err != nil {
+			return nil, err
+		}
+	}
+
+	if tmpl, ok := parsed.(*template.Template); ok {
+		text, err := execute(tmpl, data)
+		if err != nil {
+			return nil, err
+		}
+		if !json.Valid([]byte(text)) {
+			return nil, fmt.Errorf("payload template did not produce valid JSON")
+		}
+		return []byte(text), nil
+	}
+
+	value, err := renderJSON(parsed, data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// parse returns Text as a template, or a copy of JSON with every string
+// parsed as a template.
+func (p *PayloadTemplate) parse() (interface{}, error) {
+	if p.JSON == nil {
+		return parseTemplate("payload_template", p.Text)
+	}
+	return parseJSON("payload_template", p.JSON)
+}
+
+func parseJSON(name string, v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case string:
+		return parseTemplate(name, value)
+	case map[string]interface{}:
+		parsed := make(map[string]interface{}, len(value))
+		for key, elem := range value {
+			p, err := parseJSON(name+"."+key, elem)
+			if err != nil {
+				return nil, err
+			}
+			parsed[key] = p
+		}
+		return parsed, nil
+	case []interface{}:
+		parsed := make([]interface{}, len(value))
+		for i, elem := range value {
+			p, err := parseJSON(fmt.Sprintf("%s[%d]", name, i), elem)
+			if err != nil {
+				return nil, err
+			}
+			parsed[i] = p
+		}
+		return parsed, nil
+	default:
+		return value, nil
+	}
+}
+
+// renderJSON returns a copy of a parsed JSON template with every template
+// executed.
+func renderJSON(v interface{}, data PayloadData) (interface{}, error) {
+	switch value := v.(type) {
+	case *template.Template:
+		return execute(value, data)
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(value))
+		for key, elem := range value {
+			r, err := renderJSON(elem, data)
+			if err != nil {
+				return nil, err
+			}
+			rendered[key] = r
+		}
+		return rendered, nil
+	case []interface{}:
+		rendered := make([]interface{}, len(value))
+		for i, elem := range value {
+			r, err := renderJSON(elem, data)
+			if err != nil {
+				return nil, err
+			}
+			rendered[i] = r
+		}
+		return rendered, nil
+	default:
+		return value, nil
+	}
+}
+
+func execute(tmpl *template.Template, data interface{}) (string, error) {
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+}
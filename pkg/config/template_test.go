@@ -0,0 +1,175 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func testPayloadData() PayloadData {
+	return PayloadData{
+		Command: "report",
+		Fields: map[string]interface{}{
+			"title":  "Printer on fire",
+			"labels": []interface{}{"bug", "urgent"},
+			"notes":  "",
+		},
+		User:      PayloadUser{ID: "123", Username: "alice", DisplayName: "Alice"},
+		GuildID:   "456",
+		ChannelID: "789",
+		Timestamp: "2024-05-01T12:00:00Z",
+	}
+}
+
+func TestPayloadTemplate_Render(t *testing.T) {
+	tests := []struct {
+		name     string
+		template PayloadTemplate
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "text template",
+			template: PayloadTemplate{Text: `{"summary": {{ json .Fields.title }}, "reporter": "{{ .User.Username }}"}`},
+			want:     `{"summary":"Printer on fire","reporter":"alice"}`,
+		},
+		{
+			name:     "functions",
+			template: PayloadTemplate{Text: `{"labels": "{{ join "," .Fields.labels }}", "notes": "{{ default "none" .Fields.notes }}", "cmd": "{{ upper .Command }}"}`},
+			want:     `{"labels":"bug,urgent","notes":"none","cmd":"REPORT"}`,
+		},
+		{
+			name: "JSON template",
+			template: PayloadTemplate{JSON: map[string]interface{}{
+				"fields": map[string]interface{}{
+					"summary": "[{{ .Command }}] {{ .Fields.title }}",
+					"labels":  []interface{}{"yambot", "{{ .GuildID }}"},
+					"weight":  3,
+				},
+				"created": "{{ .Timestamp }}",
+			}},
+			want: `{"created":"2024-05-01T12:00:00Z","fields":{"labels":["yambot","456"],"summary":"[report] Printer on fire","weight":3}}`,
+		},
+		{
+			name:     "text template producing invalid JSON",
+			template: PayloadTemplate{Text: `{"summary": "{{ .Fields.title }}"`},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := tt.template.Render(testPayloadData())
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got body %s", body)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to render template: %v", err)
+			}
+
+			var got, want interface{}
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("Rendered body is not JSON: %v", err)
+			}
+			json.Unmarshal([]byte(tt.want), &want)
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("Expected %s, got %s", wantJSON, gotJSON)
+			}
+		})
+	}
+}
+
+func TestValidate_ParsesTemplates(t *testing.T) {
+	cfg := validConfig()
+	cfg.Commands[0].PayloadTemplate = &PayloadTemplate{JSON: map[string]interface{}{"summary": "{{ .Fields.title }}"}}
+	cfg.Commands[0].Response = &ResponseSpec{Success: "Thanks {{ .User.DisplayName }}"}
+	cfg.Commands[0].Notice = &NoticeSpec{Channel: "123456789012345678", Message: "New {{ .Command }}"}
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Expected valid config, got: %v", err)
+	}
+
+	cmd := cfg.Commands[0]
+	if cmd.PayloadTemplate.parsed == nil || cmd.Response.parsed["success"] == nil || cmd.Notice.parsed == nil {
+		t.Fatal("Expected the templates to be parsed during validation")
+	}
+
+	// Render uses the parsed templates, not the text they were parsed from
+	cmd.PayloadTemplate.JSON = nil
+	cmd.Response.Success = ""
+	cmd.Notice.Message = ""
+
+	body, err := cmd.PayloadTemplate.Render(testPayloadData())
+	if err != nil || string(body) != `{"summary":"Printer on fire"}` {
+		t.Errorf("Expected the parsed payload template, got %s (%v)", body, err)
+	}
+	response, err := cmd.Response.Render("success", ResponseData{User: PayloadUser{DisplayName: "Jane"}})
+	if err != nil || response != "Thanks Jane" {
+		t.Errorf("Expected the parsed response template, got '%s' (%v)", response, err)
+	}
+	notice, err := cmd.Notice.Render(ResponseData{Command: "report"})
+	if err != nil || notice != "New report" {
+		t.Errorf("Expected the parsed notice template, got '%s' (%v)", notice, err)
+	}
+}
+
+func TestLoadConfigPayloadTemplate(t *testing.T) {
+	testConfig := `bot:
+  discord:
+    token: TEST_TOKEN
+
+commands:
+  - name: text
+    type: slash
+    webhook: "https://example.com/text"
+    payload_template: |
+      {"title": {{ json .Fields.title }}}
+    fields:
+      - name: title
+        type: text
+  - name: jira
+    type: slash
+    webhook: "https://example.com/jira"
+    payload_template:
+      fields:
+        project:
+          key: OPS
+        summary: "{{ .Fields.title }}"
+    fields:
+      - name: title
+        type: text`
+
+	tmpFile, err := os.CreateTemp("", "test-config-*.yml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(testConfig); err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	cfg, err := LoadConfig(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.Commands[0].PayloadTemplate == nil || cfg.Commands[0].PayloadTemplate.Text == "" {
+		t.Errorf("Expected text template, got %+v", cfg.Commands[0].PayloadTemplate)
+	}
+
+	body, err := cfg.Commands[1].PayloadTemplate.Render(testPayloadData())
+	if err != nil {
+		t.Fatalf("Failed to render template: %v", err)
+	}
+	want := `{"fields":{"project":{"key":"OPS"},"summary":"Printer on fire"}}`
+	if string(body) != want {
+		t.Errorf("Expected %s, got %s", want, body)
+	}
+}
@@ -0,0 +1,754 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	maxSlashOptions  = 25
+	maxSelectOptions = 25
+	maxOptionLength  = 6000
+	maxRetryAttempts = 10
+	// maxRetryWindow keeps webhook retries, which run while the user waits,
+	// well within the 15 minutes an interaction token can be used.
+	maxRetryWindow = 10 * time.Minute
+)
+
+// commandNamePattern mirrors Discord's naming rules for commands and options.
+var commandNamePattern = regexp.MustCompile(`^[-_\p{Ll}\p{N}]{1,32}$`)
+
+// snowflakePattern matches Discord IDs such as guild, role and user IDs.
+var snowflakePattern = regexp.MustCompile(`^[0-9]{15,21}$`)
+
+var commandTypes = map[string]bool{
+	"slash": true,
+	"modal": true,
+}
+
+var fieldTypes = map[string]bool{
+	"text":          true,
+	"textarea":      true,
+	"select":        true,
+	"remote_select": true,
+	"attachment":    true,
+	"integer":       true,
+	"number":        true,
+	"boolean":       true,
+	"user":          true,
+	"channel":       true,
+	"role":          true,
+	"mentionable":   true,
+}
+
+// stringFieldTypes lists the field types whose value is free text, which is
+// what min_length and max_length apply to.
+var stringFieldTypes = map[string]bool{
+	"text":     true,
+	"textarea": true,
+}
+
+// ChannelTypes lists the names accepted in a field's channel_types.
+var ChannelTypes = []string{
+	"text", "dm", "voice", "group_dm", "category", "announcement",
+	"announcement_thread", "public_thread", "private_thread",
+	"stage", "directory", "forum", "media",
+}
+
+// modalFieldTypes lists the field types a Discord modal can render.
+var modalFieldTypes = map[string]bool{
+	"text":          true,
+	"textarea":      true,
+	"select":        true,
+	"remote_select": true,
+}
+
+// Problem describes a single invalid setting in the configuration.
+type Problem struct {
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.Path, p.Message)
+}
+
+// ValidationError collects every problem found while validating a Config.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		lines = append(lines, "  - "+p.String())
+	}
+	return fmt.Sprintf("invalid config (%d problems):\n%s", len(e.Problems), strings.Join(lines, "\n"))
+}
+
+func (e *ValidationError) add(path, format string, args ...interface{}) {
+	e.Problems = append(e.Problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks the whole configuration tree and returns a *ValidationError
+// listing every problem found, or nil when the configuration is usable.
+func (c *Config) Validate() error {
+	verr := &ValidationError{}
+
+	if strings.TrimSpace(c.Bot.Discord.Token) == "" {
+		verr.add("bot.discord.token", "is required")
+	}
+
+	for i, guildID := range c.Bot.Discord.Guilds {
+		validateSnowflake(verr, fmt.Sprintf("bot.discord.guilds[%d]", i), guildID)
+	}
+
+	if c.Bot.Webhook.Retry != nil {
+		validateRetry(verr, "bot.webhook.retry", c.Bot.Webhook.Retry)
+	}
+
+	if c.Bot.Outbox.Interval < 0 {
+		verr.add("bot.outbox.interval", "must not be negative")
+	}
+	if c.Bot.Outbox.MaxAttempts < 0 {
+		verr.add("bot.outbox.max_attempts", "must not be negative")
+	}
+
+	// Command names only have to be unique within the scope (global or a
+	// guild) they are registered in.
+	seen := make(map[string]int)
+	reported := make(map[string]bool)
+	for i, cmd := range c.Commands {
+		path := fmt.Sprintf("commands[%d]", i)
+		for _, guildID := range c.GuildsFor(cmd) {
+			key := guildID + "/" + cmd.Name
+			if first, ok := seen[key]; ok && cmd.Name != "" {
+				verr.add(path+".name", "duplicate command name %q (already used by commands[%d])", cmd.Name, first)
+				break
+			}
+			seen[key] = i
+		}
+		validateCommand(verr, path, cmd)
+		c.validateRetryWindow(verr, path, cmd, reported)
+	}
+
+	if len(verr.Problems) > 0 {
+		return verr
+	}
+	return nil
+}
+
+func validateCommand(verr *ValidationError, path string, cmd CommandSpec) {
+	validateName(verr, path+".name", cmd.Name)
+
+	if !commandTypes[cmd.Type] {
+		verr.add(path+".type", "unknown command type %q (expected slash or modal)", cmd.Type)
+	}
+
+	if cmd.Webhook.IsSet() {
+		validateWebhook(verr, path+".webhook", cmd.Webhook, commandWebhookMethods)
+		if len(cmd.Webhooks) > 0 {
+			verr.add(path+".webhooks", "cannot be combined with webhook")
+		}
+	}
+
+	targetNames := make(map[string]int)
+	for i, target := range cmd.Webhooks {
+		targetPath := fmt.Sprintf("%s.webhooks[%d]", path, i)
+		if first, ok := targetNames[target.Name]; ok && target.Name != "" {
+			verr.add(targetPath+".name", "duplicate webhook name %q (already used by webhooks[%d])", target.Name, first)
+		} else {
+			targetNames[target.Name] = i
+		}
+		validateTarget(verr, targetPath, target, cmd)
+	}
+
+	for i, guildID := range cmd.Guilds {
+		validateSnowflake(verr, fmt.Sprintf("%s.guilds[%d]", path, i), guildID)
+	}
+
+	if cmd.Access != nil {
+		validateAccess(verr, path+".access", cmd.Access)
+	}
+
+	if cmd.Retry != nil {
+		validateRetry(verr, path+".retry", cmd.Retry)
+	}
+
+	if cmd.Secret != "" {
+		if strings.TrimSpace(cmd.Secret) == "" {
+			verr.add(path+".secret", "must not be blank")
+		}
+		if !cmd.HasWebhook() {
+			verr.add(path+".secret", "is only used to sign webhook requests, but the command has no webhook")
+		}
+	}
+
+	if cmd.PayloadTemplate != nil {
+		if !cmd.HasWebhook() {
+			verr.add(path+".payload_template", "shapes the webhook body, but the command has no webhook")
+		}
+		validatePayloadTemplate(verr, path+".payload_template", cmd.PayloadTemplate, cmd)
+		if cmd.LegacyPayload != nil && *cmd.LegacyPayload {
+			verr.add(path+".legacy_payload", "has no effect with payload_template")
+		}
+	}
+
+	if cmd.Attachments != nil {
+		validateAttachments(verr, path+".attachments", cmd)
+	}
+
+	if cmd.Response != nil {
+		validateResponse(verr, path+".response", cmd)
+	}
+
+	if cmd.Visibility != "" && !slices.Contains(Visibilities, cmd.Visibility) {
+		verr.add(path+".visibility", "unknown visibility %q (expected one of %s)", cmd.Visibility, strings.Join(Visibilities, ", "))
+	}
+
+	if cmd.Notice != nil {
+		validateNotice(verr, path+".notice", cmd)
+	}
+
+	switch cmd.Type {
+	case "slash":
+		if len(cmd.Fields) > maxSlashOptions {
+			verr.add(path+".fields", "slash commands support at most %d fields, got %d", maxSlashOptions, len(cmd.Fields))
+		}
+	case "modal":
+		if len(cmd.Fields) == 0 {
+			verr.add(path+".fields", "modal commands need at least one field")
+		}
+	}
+
+	seen := make(map[string]int)
+	for i, field := range cmd.Fields {
+		fieldPath := fmt.Sprintf("%s.fields[%d]", path, i)
+		if first, ok := seen[field.Name]; ok && field.Name != "" {
+			verr.add(fieldPath+".name", "duplicate field name %q (already used by fields[%d])", field.Name, first)
+		} else {
+			seen[field.Name] = i
+		}
+		validateField(verr, fieldPath, cmd.Type, field)
+	}
+}
+
+func validateTarget(verr *ValidationError, path string, target WebhookTarget, cmd CommandSpec) {
+	if target.Name != "" {
+		validateName(verr, path+".name", target.Name)
+	}
+
+	if !target.Webhook.IsSet() {
+		verr.add(path+".url", "is required")
+	} else {
+		validateWebhook(verr, path, target.Webhook, commandWebhookMethods)
+	}
+
+	if target.Retry != nil {
+		validateRetry(verr, path+".retry", target.Retry)
+	}
+
+	if target.Secret != "" && strings.TrimSpace(target.Secret) == "" {
+		verr.add(path+".secret", "must not be blank")
+	}
+
+	if target.PayloadTemplate != nil {
+		validatePayloadTemplate(verr, path+".payload_template", target.PayloadTemplate, cmd)
+	}
+}
+
+func validateField(verr *ValidationError, path, commandType string, field FieldSpec) {
+	validateName(verr, path+".name", field.Name)
+
+	if !fieldTypes[field.Type] {
+		verr.add(path+".type", "unknown field type %q", field.Type)
+		return
+	}
+
+	switch commandType {
+	case "slash":
+		if field.Type == "textarea" {
+			verr.add(path+".type", "textarea fields are only supported in modal commands")
+		}
+	case "modal":
+		if !modalFieldTypes[field.Type] {
+			verr.add(path+".type", "%s fields cannot be rendered in a modal (only text, textarea, select and remote_select are supported)", field.Type)
+		}
+	}
+
+	switch field.Type {
+	case "select":
+		if len(field.Options) == 0 {
+			verr.add(path+".options", "select fields need at least one option")
+		}
+		if len(field.Options) > maxSelectOptions {
+			verr.add(path+".options", "select fields support at most %d options, got %d", maxSelectOptions, len(field.Options))
+		}
+		for i, option := range field.Options {
+			if strings.TrimSpace(option) == "" {
+				verr.add(fmt.Sprintf("%s.options[%d]", path, i), "must not be empty")
+			}
+		}
+	case "remote_select":
+		if !field.Webhook.IsSet() {
+			verr.add(path+".webhook", "remote_select fields need a webhook")
+		} else {
+			validateWebhook(verr, path+".webhook", field.Webhook, optionWebhookMethods)
+		}
+	}
+
+	validateFieldBounds(verr, path, field)
+	validateSelectBounds(verr, path, commandType, field)
+
+	if field.Validate != nil {
+		validateRules(verr, path+".validate", field)
+	}
+}
+
+func validateFieldBounds(verr *ValidationError, path string, field FieldSpec) {
+	isNumeric := field.Type == "integer" || field.Type == "number"
+	if !isNumeric && (field.MinValue != nil || field.MaxValue != nil) {
+		verr.add(path, "min_value and max_value only apply to integer and number fields")
+	}
+	// Discord's option omits a zero maximum, which would leave the option
+	// without an upper bound
+	if field.MaxValue != nil && *field.MaxValue == 0 {
+		verr.add(path+".max_value", "cannot be 0, which Discord treats as no maximum; use a nonzero bound or leave it out")
+	}
+	if field.MinValue != nil && field.MaxValue != nil && *field.MinValue > *field.MaxValue {
+		verr.add(path+".min_value", "must not be greater than max_value")
+	}
+
+	if !stringFieldTypes[field.Type] && (field.MinLength != nil || field.MaxLength != nil) {
+		verr.add(path, "min_length and max_length only apply to text and textarea fields")
+	}
+	if field.MinLength != nil && (*field.MinLength < 0 || *field.MinLength > maxOptionLength) {
+		verr.add(path+".min_length", "must be between 0 and %d", maxOptionLength)
+	}
+	if field.MaxLength != nil && (*field.MaxLength < 1 || *field.MaxLength > maxOptionLength) {
+		verr.add(path+".max_length", "must be between 1 and %d", maxOptionLength)
+	}
+	if field.MinLength != nil && field.MaxLength != nil && *field.MinLength > *field.MaxLength {
+		verr.add(path+".min_length", "must not be greater than max_length")
+	}
+
+	if field.Type != "channel" && len(field.ChannelTypes) > 0 {
+		verr.add(path+".channel_types", "only applies to channel fields")
+	}
+	for i, channelType := range field.ChannelTypes {
+		if !slices.Contains(ChannelTypes, channelType) {
+			verr.add(fmt.Sprintf("%s.channel_types[%d]", path, i), "unknown channel type %q (expected one of %s)", channelType, strings.Join(ChannelTypes, ", "))
+		}
+	}
+}
+
+func validateSelectBounds(verr *ValidationError, path, commandType string, field FieldSpec) {
+	if field.MinValues == nil && field.MaxValues == nil {
+		return
+	}
+
+	isSelect := field.Type == "select" || field.Type == "remote_select"
+	if !isSelect || commandType != "modal" {
+		verr.add(path, "min_values and max_values only apply to select and remote_select fields in modal commands")
+		return
+	}
+
+	if field.MinValues != nil && (*field.MinValues < 0 || *field.MinValues > maxSelectOptions) {
+		verr.add(path+".min_values", "must be between 0 and %d", maxSelectOptions)
+	}
+	if field.MaxValues != nil && (*field.MaxValues < 1 || *field.MaxValues > maxSelectOptions) {
+		verr.add(path+".max_values", "must be between 1 and %d", maxSelectOptions)
+	}
+	if field.MinValues != nil && field.MaxValues != nil && *field.MinValues > *field.MaxValues {
+		verr.add(path+".min_values", "must not be greater than max_values")
+	}
+	if field.Type == "select" && len(field.Options) > 0 {
+		if field.MaxValues != nil && *field.MaxValues > len(field.Options) {
+			verr.add(path+".max_values", "must not exceed the number of options (%d)", len(field.Options))
+		}
+		if field.MinValues != nil && *field.MinValues > len(field.Options) {
+			verr.add(path+".min_values", "must not exceed the number of options (%d)", len(field.Options))
+		}
+	}
+	if field.Required && field.MinValues != nil && *field.MinValues == 0 {
+		verr.add(path+".min_values", "must be at least 1 for required fields")
+	}
+}
+
+func validateRules(verr *ValidationError, path string, field FieldSpec) {
+	rules := field.Validate
+
+	if !stringFieldTypes[field.Type] {
+		verr.add(path, "only applies to text and textarea fields")
+	}
+
+	if rules.Format != "" && !slices.Contains(Formats, rules.Format) {
+		verr.add(path+".format", "unknown format %q (expected one of %s)", rules.Format, strings.Join(Formats, ", "))
+	}
+
+	if rules.Pattern != "" {
+		pattern, err := regexp.Compile(anchorPattern(rules.Pattern))
+		if err != nil {
+			verr.add(path+".pattern", "invalid regular expression: %v", err)
+		} else {
+			rules.pattern = pattern
+		}
+	}
+
+	if rules.MinLength != nil && *rules.MinLength < 0 {
+		verr.add(path+".min_length", "must not be negative")
+	}
+	if rules.MaxLength != nil && *rules.MaxLength < 1 {
+		verr.add(path+".max_length", "must be at least 1")
+	}
+	if rules.MinLength != nil && rules.MaxLength != nil && *rules.MinLength > *rules.MaxLength {
+		verr.add(path+".min_length", "must not be greater than max_length")
+	}
+
+	if (rules.Min != nil || rules.Max != nil) && rules.Format != "number" && rules.Format != "integer" {
+		verr.add(path, "min and max only apply to the number and integer formats")
+	}
+	if rules.Min != nil && rules.Max != nil && *rules.Min > *rules.Max {
+		verr.add(path+".min", "must not be greater than max")
+	}
+}
+
+func validateRetry(verr *ValidationError, path string, retry *RetryPolicy) {
+	if retry.MaxAttempts < 0 || retry.MaxAttempts > maxRetryAttempts {
+		verr.add(path+".max_attempts", "must be between 1 and %d (0 keeps the inherited value)", maxRetryAttempts)
+	}
+	if retry.BaseDelay < 0 {
+		verr.add(path+".base_delay", "must not be negative")
+	}
+	if retry.MaxDelay < 0 {
+		verr.add(path+".max_delay", "must not be negative")
+	}
+	if retry.BaseDelay > 0 && retry.MaxDelay > 0 && retry.BaseDelay > retry.MaxDelay {
+		verr.add(path+".base_delay", "must not be greater than max_delay")
+	}
+	for i, status := range retry.RetryOn {
+		if status < 400 || status > 599 {
+			verr.add(fmt.Sprintf("%s.retry_on[%d]", path, i), "%d is not an HTTP error status (400-599)", status)
+		}
+	}
+}
+
+// validateRetryWindow checks that the effective retry policy of each of a
+// command's destinations gives up within maxRetryWindow. The problem is
+// reported on the most specific retry block that applies, and only once for
+// the global policy.
+func (c *Config) validateRetryWindow(verr *ValidationError, path string, cmd CommandSpec, reported map[string]bool) {
+	for i, target := range cmd.Targets() {
+		policy := c.RetryForTarget(cmd, target)
+		timeout := target.Webhook.RequestTimeout()
+		window := policy.Window(timeout)
+		if window <= maxRetryWindow {
+			continue
+		}
+
+		retryPath := "bot.webhook.retry"
+		switch {
+		case len(cmd.Webhooks) > 0 && (cmd.Webhooks[i].Retry != nil || cmd.Webhooks[i].Webhook.Timeout > 0):
+			retryPath = fmt.Sprintf("%s.webhooks[%d].retry", path, i)
+		case cmd.Retry != nil || target.Webhook.Timeout > 0:
+			retryPath = path + ".retry"
+		}
+		if reported[retryPath] {
+			continue
+		}
+		reported[retryPath] = true
+
+		verr.add(retryPath, "deliveries can take up to %s (%d attempts with a %s timeout and up to %s between them), more than the %s limit; lower max_attempts, max_delay or the webhook timeout",
+			window, max(policy.MaxAttempts, 1), timeout, policy.MaxDelay, maxRetryWindow)
+	}
+}
+
+func validateAccess(verr *ValidationError, path string, access *AccessSpec) {
+	lists := []struct {
+		name string
+		ids  []string
+	}{
+		{"allow_roles", access.AllowRoles},
+		{"deny_roles", access.DenyRoles},
+		{"allow_users", access.AllowUsers},
+		{"deny_users", access.DenyUsers},
+		{"allow_channels", access.AllowChannels},
+		{"deny_channels", access.DenyChannels},
+	}
+	for _, list := range lists {
+		for i, id := range list.ids {
+			validateSnowflake(verr, fmt.Sprintf("%s.%s[%d]", path, list.name, i), id)
+		}
+	}
+
+	for i, name := range access.Permissions {
+		if _, ok := permissionFlags[name]; !ok {
+			verr.add(fmt.Sprintf("%s.permissions[%d]", path, i), "unknown permission %q", name)
+		}
+	}
+}
+
+func validatePayloadTemplate(verr *ValidationError, path string, p *PayloadTemplate, cmd CommandSpec) {
+	if p.JSON == nil {
+		if strings.TrimSpace(p.Text) == "" {
+			verr.add(path, "must not be empty")
+			return
+		}
+		if _, err := parseTemplate("payload_template", p.Text); err != nil {
+			verr.add(path, "invalid template: %v", err)
+			return
+		}
+	} else {
+		problems := len(verr.Problems)
+		validateJSONTemplate(verr, path, p.JSON)
+		if len(verr.Problems) > problems {
+			return
+		}
+	}
+
+	parsed, err := p.parse()
+	if err != nil {
+		verr.add(path, "invalid template: %v", err)
+		return
+	}
+	p.parsed = parsed
+	if _, err := renderJSON(parsed, samplePayloadData(cmd)); err != nil {
+		verr.add(path, "fails to render: %v", err)
+	}
+}
+
+func validateJSONTemplate(verr *ValidationError, path string, v interface{}) {
+	switch value := v.(type) {
+	case string:
+		if _, err := parseTemplate("payload_template", value); err != nil {
+			verr.add(path, "invalid template: %v", err)
+		}
+	case map[string]interface{}:
+		// Sorted so problems are reported in a stable order
+		for _, key := range slices.Sorted(maps.Keys(value)) {
+			validateJSONTemplate(verr, path+"."+key, value[key])
+		}
+	case []interface{}:
+		for i, elem := range value {
+			validateJSONTemplate(verr, fmt.Sprintf("%s[%d]", path, i), elem)
+		}
+	case map[interface{}]interface{}:
+		verr.add(path, "keys must be strings")
+	}
+}
+
+func validateResponse(verr *ValidationError, path string, cmd CommandSpec) {
+	response := cmd.Response
+	response.parsed = make(map[string]*template.Template)
+
+	templates := []struct {
+		name, text string
+	}{
+		{"success", response.Success},
+		{"failure", response.Failure},
+		{"validation", response.Validation},
+	}
+	for _, tmpl := range templates {
+		if tmpl.text == "" {
+			continue
+		}
+		if strings.TrimSpace(tmpl.text) == "" {
+			verr.add(path+"."+tmpl.name, "must not be blank")
+			continue
+		}
+		validateResponseTemplate(verr, path+"."+tmpl.name, response, tmpl.name, cmd)
+	}
+
+	if response.Style != "" && !slices.Contains(ResponseStyles, response.Style) {
+		verr.add(path+".style", "unknown response style %q (expected one of %s)", response.Style, strings.Join(ResponseStyles, ", "))
+	}
+
+	if embed := response.Embed; embed != nil {
+		if !response.UsesEmbed() {
+			verr.add(path+".embed", "is only used with style: embed")
+		}
+		if _, ok := embed.ColorValue(); embed.Color != "" && !ok {
+			verr.add(path+".embed.color", "invalid colour %q (expected a hex colour such as #5865F2)", embed.Color)
+		}
+		if embed.Title != "" {
+			validateResponseTemplate(verr, path+".embed.title", response, "embed.title", cmd)
+		}
+		if embed.Thumbnail != "" && embed.Thumbnail != AvatarThumbnail {
+			validateWebhookURL(verr, path+".embed.thumbnail", embed.Thumbnail)
+		}
+	}
+
+	if !cmd.HasWebhook() {
+		if response.Failure != "" {
+			verr.add(path+".failure", "is shown when the webhook fails, but the command has no webhook")
+		}
+		if response.HideEndpoint {
+			verr.add(path+".hide_endpoint", "hides the webhook URL, but the command has no webhook")
+		}
+	}
+}
+
+// validateResponseTemplate parses the response template called name and
+// keeps it for Render when it renders.
+func validateResponseTemplate(verr *ValidationError, path string, response *ResponseSpec, name string, cmd CommandSpec) {
+	tmpl, err := parseTemplate("response."+name, response.templateText(name))
+	if err != nil {
+		verr.add(path, "invalid template: %v", err)
+		return
+	}
+	if _, err := execute(tmpl, sampleResponseData(cmd)); err != nil {
+		verr.add(path, "fails to render: %v", err)
+		return
+	}
+	response.parsed[name] = tmpl
+}
+
+func validateNotice(verr *ValidationError, path string, cmd CommandSpec) {
+	notice := cmd.Notice
+
+	if notice.Channel == "" {
+		verr.add(path+".channel", "is required")
+	} else {
+		validateSnowflake(verr, path+".channel", notice.Channel)
+	}
+
+	if notice.Message != "" {
+		if strings.TrimSpace(notice.Message) == "" {
+			verr.add(path+".message", "must not be blank")
+		} else if tmpl, err := parseTemplate("notice.message", notice.Message); err != nil {
+			verr.add(path+".message", "invalid template: %v", err)
+		} else if _, err := execute(tmpl, sampleResponseData(cmd)); err != nil {
+			verr.add(path+".message", "fails to render: %v", err)
+		} else {
+			notice.parsed = tmpl
+		}
+	}
+
+	for i, name := range notice.Fields {
+		known := slices.ContainsFunc(cmd.Fields, func(field FieldSpec) bool {
+			return field.Name == name
+		})
+		if !known {
+			verr.add(fmt.Sprintf("%s.fields[%d]", path, i), "unknown field %q", name)
+		}
+	}
+}
+
+// mediaRangePattern matches a media type such as "application/pdf", or a
+// whole type such as "image/*".
+var mediaRangePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9!#$&^_.+-]*/([A-Za-z0-9][A-Za-z0-9!#$&^_.+-]*|\*)$`)
+
+func validateAttachments(verr *ValidationError, path string, cmd CommandSpec) {
+	attachments := cmd.Attachments
+
+	if !slices.Contains(AttachmentModes, attachments.Mode) {
+		verr.add(path+".mode", "unknown attachment mode %q (expected one of %s)", attachments.Mode, strings.Join(AttachmentModes, ", "))
+	}
+	if attachments.MaxSize < 0 {
+		verr.add(path+".max_size", "must not be negative")
+	}
+	for j, contentType := range attachments.ContentTypes {
+		if !mediaRangePattern.MatchString(contentType) {
+			verr.add(fmt.Sprintf("%s.content_types[%d]", path, j), "invalid content type %q (expected e.g. application/pdf or image/*)", contentType)
+		}
+	}
+
+	if !cmd.HasWebhook() {
+		verr.add(path, "controls how files reach the webhook, but the command has no webhook")
+	}
+	hasAttachmentField := slices.ContainsFunc(cmd.Fields, func(field FieldSpec) bool {
+		return field.Type == "attachment"
+	})
+	if !hasAttachmentField {
+		verr.add(path, "the command has no attachment fields")
+	}
+}
+
+func validateName(verr *ValidationError, path, name string) {
+	if name == "" {
+		verr.add(path, "is required")
+		return
+	}
+	if !commandNamePattern.MatchString(name) {
+		verr.add(path, "%q must be 1-32 lowercase letters, digits, '-' or '_'", name)
+	}
+}
+
+func validateSnowflake(verr *ValidationError, path, id string) {
+	if !snowflakePattern.MatchString(id) {
+		verr.add(path, "%q is not a valid Discord ID", id)
+	}
+}
+
+// commandWebhookMethods and optionWebhookMethods list the HTTP methods allowed
+// for command webhooks, which receive a body, and remote_select option
+// sources.
+var (
+	commandWebhookMethods = []string{"POST", "PUT", "PATCH"}
+	optionWebhookMethods  = []string{"GET", "POST"}
+)
+
+// httpHeaderPattern matches valid HTTP header names.
+var httpHeaderPattern = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+func validateWebhook(verr *ValidationError, path string, webhook WebhookSpec, methods []string) {
+	validateWebhookURL(verr, path, webhook.URL)
+
+	if webhook.Method != "" && !slices.Contains(methods, webhook.Method) {
+		verr.add(path+".method", "unsupported method %q (expected one of %s)", webhook.Method, strings.Join(methods, ", "))
+	}
+
+	for name := range webhook.Headers {
+		if !httpHeaderPattern.MatchString(name) {
+			verr.add(path+".headers", "invalid header name %q", name)
+		}
+	}
+
+	if webhook.Timeout < 0 {
+		verr.add(path+".timeout", "must not be negative")
+	}
+
+	if auth := webhook.Auth; auth != nil {
+		authPath := path + ".auth"
+		switch auth.Type {
+		case "bearer":
+			if auth.Token == "" {
+				verr.add(authPath+".token", "is required for bearer auth")
+			}
+		case "basic":
+			if auth.Username == "" {
+				verr.add(authPath+".username", "is required for basic auth")
+			}
+		case "header":
+			if !httpHeaderPattern.MatchString(auth.Header) {
+				verr.add(authPath+".header", "must be a valid header name for header auth")
+			}
+			if auth.Value == "" {
+				verr.add(authPath+".value", "is required for header auth")
+			}
+		default:
+			verr.add(authPath+".type", "unknown auth type %q (expected one of %s)", auth.Type, strings.Join(AuthTypes, ", "))
+		}
+	}
+}
+
+func validateWebhookURL(verr *ValidationError, path, raw string) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		verr.add(path, "invalid URL %q: %v", raw, err)
+		return
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		verr.add(path, "URL %q must use http or https", raw)
+		return
+	}
+	if u.Host == "" {
+		verr.add(path, "URL %q has no host", raw)
+	}
+}
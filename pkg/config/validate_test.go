@@ -0,0 +1,589 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func validConfig() *Config {
+	return &Config{
+		Bot: BotConfig{Discord: DiscordConfig{Token: "TEST_TOKEN"}},
+		Commands: []CommandSpec{
+			{
+				Name:    "report",
+				Type:    "slash",
+				Webhook: WebhookSpec{URL: "https://example.com/webhook"},
+				Fields: []FieldSpec{
+					{Name: "title", Type: "text", Required: true},
+					{Name: "priority", Type: "select", Options: []string{"Low", "High"}},
+					{Name: "team", Type: "remote_select", Webhook: WebhookSpec{URL: "https://example.com/teams"}},
+					{Name: "file", Type: "attachment"},
+				},
+			},
+			{
+				Name: "feedback",
+				Type: "modal",
+				Fields: []FieldSpec{
+					{Name: "subject", Type: "text", Required: true},
+					{Name: "message", Type: "textarea"},
+				},
+			},
+		},
+	}
+}
+
+func TestValidate_ValidConfig(t *testing.T) {
+	if err := validConfig().Validate(); err != nil {
+		t.Errorf("Expected valid config, got: %v", err)
+	}
+}
+
+func TestValidate_Problems(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		path   string
+	}{
+		{
+			name:   "missing token",
+			modify: func(c *Config) { c.Bot.Discord.Token = "" },
+			path:   "bot.discord.token",
+		},
+		{
+			name:   "unknown command type",
+			modify: func(c *Config) { c.Commands[0].Type = "slahs" },
+			path:   "commands[0].type",
+		},
+		{
+			name:   "uppercase command name",
+			modify: func(c *Config) { c.Commands[0].Name = "Report" },
+			path:   "commands[0].name",
+		},
+		{
+			name:   "command name too long",
+			modify: func(c *Config) { c.Commands[0].Name = strings.Repeat("a", 33) },
+			path:   "commands[0].name",
+		},
+		{
+			name:   "duplicate command name",
+			modify: func(c *Config) { c.Commands[1].Name = "report" },
+			path:   "commands[1].name",
+		},
+		{
+			name:   "invalid guild ID",
+			modify: func(c *Config) { c.Commands[0].Guilds = []string{"ops-server"} },
+			path:   "commands[0].guilds[0]",
+		},
+		{
+			name:   "invalid default guild ID",
+			modify: func(c *Config) { c.Bot.Discord.Guilds = []string{"123"} },
+			path:   "bot.discord.guilds[0]",
+		},
+		{
+			name:   "invalid access role ID",
+			modify: func(c *Config) { c.Commands[0].Access = &AccessSpec{AllowRoles: []string{"admins"}} },
+			path:   "commands[0].access.allow_roles[0]",
+		},
+		{
+			name:   "unknown access permission",
+			modify: func(c *Config) { c.Commands[0].Access = &AccessSpec{Permissions: []string{"manage_everything"}} },
+			path:   "commands[0].access.permissions[0]",
+		},
+		{
+			name: "min_value on text field",
+			modify: func(c *Config) {
+				minValue := 1.0
+				c.Commands[0].Fields[0].MinValue = &minValue
+			},
+			path: "commands[0].fields[0]",
+		},
+		{
+			name: "min_value greater than max_value",
+			modify: func(c *Config) {
+				minValue, maxValue := 10.0, 1.0
+				c.Commands[0].Fields[0] = FieldSpec{Name: "count", Type: "integer", MinValue: &minValue, MaxValue: &maxValue}
+			},
+			path: "commands[0].fields[0].min_value",
+		},
+		{
+			name: "zero max_value",
+			modify: func(c *Config) {
+				minValue, maxValue := -10.0, 0.0
+				c.Commands[0].Fields[0] = FieldSpec{Name: "delta", Type: "integer", MinValue: &minValue, MaxValue: &maxValue}
+			},
+			path: "commands[0].fields[0].max_value",
+		},
+		{
+			name: "max_length out of range",
+			modify: func(c *Config) {
+				maxLength := 7000
+				c.Commands[0].Fields[0].MaxLength = &maxLength
+			},
+			path: "commands[0].fields[0].max_length",
+		},
+		{
+			name: "unknown channel type",
+			modify: func(c *Config) {
+				c.Commands[0].Fields[0] = FieldSpec{Name: "where", Type: "channel", ChannelTypes: []string{"txt"}}
+			},
+			path: "commands[0].fields[0].channel_types[0]",
+		},
+		{
+			name: "typed field in modal",
+			modify: func(c *Config) {
+				c.Commands[1].Fields[0] = FieldSpec{Name: "count", Type: "integer"}
+			},
+			path: "commands[1].fields[0].type",
+		},
+		{
+			name: "unknown validate format",
+			modify: func(c *Config) {
+				c.Commands[0].Fields[0].Validate = &ValidationRules{Format: "postcode"}
+			},
+			path: "commands[0].fields[0].validate.format",
+		},
+		{
+			name: "invalid validate pattern",
+			modify: func(c *Config) {
+				c.Commands[0].Fields[0].Validate = &ValidationRules{Pattern: "[a-z"}
+			},
+			path: "commands[0].fields[0].validate.pattern",
+		},
+		{
+			name: "validate min without numeric format",
+			modify: func(c *Config) {
+				minValue := 1.0
+				c.Commands[0].Fields[0].Validate = &ValidationRules{Format: "email", Min: &minValue}
+			},
+			path: "commands[0].fields[0].validate",
+		},
+		{
+			name: "validate on select field",
+			modify: func(c *Config) {
+				c.Commands[0].Fields[1].Validate = &ValidationRules{Format: "email"}
+			},
+			path: "commands[0].fields[1].validate",
+		},
+		{
+			name:   "too many retry attempts",
+			modify: func(c *Config) { c.Commands[0].Retry = &RetryPolicy{MaxAttempts: 50} },
+			path:   "commands[0].retry.max_attempts",
+		},
+		{
+			name: "retry base delay above max delay",
+			modify: func(c *Config) {
+				c.Bot.Webhook.Retry = &RetryPolicy{BaseDelay: 10 * time.Second, MaxDelay: time.Second}
+			},
+			path: "bot.webhook.retry.base_delay",
+		},
+		{
+			name: "retries outlasting the interaction",
+			modify: func(c *Config) {
+				c.Commands[0].Retry = &RetryPolicy{MaxAttempts: 10, MaxDelay: 5 * time.Minute}
+			},
+			path: "commands[0].retry",
+		},
+		{
+			name: "global retries outlasting the interaction",
+			modify: func(c *Config) {
+				c.Bot.Webhook.Retry = &RetryPolicy{MaxAttempts: 5, MaxDelay: 3 * time.Minute}
+			},
+			path: "bot.webhook.retry",
+		},
+		{
+			name: "destination timeout outlasting the interaction",
+			modify: func(c *Config) {
+				c.Commands[1].Webhooks = []WebhookTarget{{
+					Webhook: WebhookSpec{URL: "https://example.com/slow", Timeout: 4 * time.Minute},
+					Retry:   &RetryPolicy{MaxAttempts: 3},
+				}}
+			},
+			path: "commands[1].webhooks[0].retry",
+		},
+		{
+			name:   "retry on success status",
+			modify: func(c *Config) { c.Commands[0].Retry = &RetryPolicy{RetryOn: []int{200}} },
+			path:   "commands[0].retry.retry_on[0]",
+		},
+		{
+			name:   "secret without webhook",
+			modify: func(c *Config) { c.Commands[1].Secret = "s3cret" },
+			path:   "commands[1].secret",
+		},
+		{
+			name:   "duplicate field name",
+			modify: func(c *Config) { c.Commands[0].Fields[1].Name = "title" },
+			path:   "commands[0].fields[1].name",
+		},
+		{
+			name:   "unknown field type",
+			modify: func(c *Config) { c.Commands[0].Fields[0].Type = "txt" },
+			path:   "commands[0].fields[0].type",
+		},
+		{
+			name:   "select without options",
+			modify: func(c *Config) { c.Commands[0].Fields[1].Options = nil },
+			path:   "commands[0].fields[1].options",
+		},
+		{
+			name:   "remote_select without webhook",
+			modify: func(c *Config) { c.Commands[0].Fields[2].Webhook = WebhookSpec{} },
+			path:   "commands[0].fields[2].webhook",
+		},
+		{
+			name:   "invalid command webhook",
+			modify: func(c *Config) { c.Commands[0].Webhook.URL = "ftp://example.com" },
+			path:   "commands[0].webhook",
+		},
+		{
+			name:   "unsupported webhook method",
+			modify: func(c *Config) { c.Commands[0].Webhook.Method = "GET" },
+			path:   "commands[0].webhook.method",
+		},
+		{
+			name:   "unsupported remote_select method",
+			modify: func(c *Config) { c.Commands[0].Fields[2].Webhook.Method = "PUT" },
+			path:   "commands[0].fields[2].webhook.method",
+		},
+		{
+			name:   "invalid webhook header name",
+			modify: func(c *Config) { c.Commands[0].Webhook.Headers = map[string]string{"X Api Key": "secret"} },
+			path:   "commands[0].webhook.headers",
+		},
+		{
+			name:   "negative webhook timeout",
+			modify: func(c *Config) { c.Commands[0].Webhook.Timeout = -time.Second },
+			path:   "commands[0].webhook.timeout",
+		},
+		{
+			name:   "unknown auth type",
+			modify: func(c *Config) { c.Commands[0].Webhook.Auth = &AuthSpec{Type: "digest"} },
+			path:   "commands[0].webhook.auth.type",
+		},
+		{
+			name:   "bearer auth without token",
+			modify: func(c *Config) { c.Commands[0].Webhook.Auth = &AuthSpec{Type: "bearer"} },
+			path:   "commands[0].webhook.auth.token",
+		},
+		{
+			name:   "header auth without header",
+			modify: func(c *Config) { c.Commands[0].Fields[2].Webhook.Auth = &AuthSpec{Type: "header", Value: "secret"} },
+			path:   "commands[0].fields[2].webhook.auth.header",
+		},
+		{
+			name: "invalid payload template",
+			modify: func(c *Config) {
+				c.Commands[0].PayloadTemplate = &PayloadTemplate{Text: `{"title": "{{ .Fields.title }"}`}
+			},
+			path: "commands[0].payload_template",
+		},
+		{
+			name: "invalid JSON payload template value",
+			modify: func(c *Config) {
+				c.Commands[0].PayloadTemplate = &PayloadTemplate{JSON: map[string]interface{}{"title": "{{ nosuchfunc }}"}}
+			},
+			path: "commands[0].payload_template.title",
+		},
+		{
+			name: "payload template failing to render",
+			modify: func(c *Config) {
+				c.Commands[0].PayloadTemplate = &PayloadTemplate{Text: `{"title": "{{ .Fields.title.text }}"}`}
+			},
+			path: "commands[0].payload_template",
+		},
+		{
+			name:   "payload template without webhook",
+			modify: func(c *Config) { c.Commands[1].PayloadTemplate = &PayloadTemplate{Text: `{}`} },
+			path:   "commands[1].payload_template",
+		},
+		{
+			name: "legacy payload with payload template",
+			modify: func(c *Config) {
+				legacy := true
+				c.Commands[0].PayloadTemplate = &PayloadTemplate{Text: `{}`}
+				c.Commands[0].LegacyPayload = &legacy
+			},
+			path: "commands[0].legacy_payload",
+		},
+		{
+			name:   "unknown attachment mode",
+			modify: func(c *Config) { c.Commands[0].Attachments = &AttachmentSpec{Mode: "upload"} },
+			path:   "commands[0].attachments.mode",
+		},
+		{
+			name: "invalid attachment content type",
+			modify: func(c *Config) {
+				c.Commands[0].Attachments = &AttachmentSpec{Mode: "forward", ContentTypes: []string{"pdf"}}
+			},
+			path: "commands[0].attachments.content_types[0]",
+		},
+		{
+			name:   "negative attachment size limit",
+			modify: func(c *Config) { c.Commands[0].Attachments = &AttachmentSpec{Mode: "base64", MaxSize: -1} },
+			path:   "commands[0].attachments.max_size",
+		},
+		{
+			name:   "attachments without attachment fields",
+			modify: func(c *Config) { c.Commands[1].Attachments = &AttachmentSpec{Mode: "forward"} },
+			path:   "commands[1].attachments",
+		},
+		{
+			name:   "invalid response template",
+			modify: func(c *Config) { c.Commands[0].Response = &ResponseSpec{Success: "Thanks {{ .User.DisplayName"} },
+			path:   "commands[0].response.success",
+		},
+		{
+			name:   "response template failing to render",
+			modify: func(c *Config) { c.Commands[0].Response = &ResponseSpec{Success: "Thanks {{ .Submitter }}"} },
+			path:   "commands[0].response.success",
+		},
+		{
+			name:   "blank validation response",
+			modify: func(c *Config) { c.Commands[0].Response = &ResponseSpec{Validation: "  "} },
+			path:   "commands[0].response.validation",
+		},
+		{
+			name:   "failure response without webhook",
+			modify: func(c *Config) { c.Commands[1].Response = &ResponseSpec{Failure: "Sorry, that did not work"} },
+			path:   "commands[1].response.failure",
+		},
+		{
+			name:   "unknown response style",
+			modify: func(c *Config) { c.Commands[0].Response = &ResponseSpec{Style: "card"} },
+			path:   "commands[0].response.style",
+		},
+		{
+			name:   "embed settings without embed style",
+			modify: func(c *Config) { c.Commands[0].Response = &ResponseSpec{Embed: &EmbedSpec{Color: "#5865F2"}} },
+			path:   "commands[0].response.embed",
+		},
+		{
+			name: "invalid embed colour",
+			modify: func(c *Config) {
+				c.Commands[0].Response = &ResponseSpec{Style: "embed", Embed: &EmbedSpec{Color: "blurple"}}
+			},
+			path: "commands[0].response.embed.color",
+		},
+		{
+			name: "invalid embed thumbnail",
+			modify: func(c *Config) {
+				c.Commands[0].Response = &ResponseSpec{Style: "embed", Embed: &EmbedSpec{Thumbnail: "logo.png"}}
+			},
+			path: "commands[0].response.embed.thumbnail",
+		},
+		{
+			name:   "unknown visibility",
+			modify: func(c *Config) { c.Commands[0].Visibility = "private" },
+			path:   "commands[0].visibility",
+		},
+		{
+			name:   "notice without channel",
+			modify: func(c *Config) { c.Commands[0].Notice = &NoticeSpec{Message: "New report"} },
+			path:   "commands[0].notice.channel",
+		},
+		{
+			name:   "invalid notice channel",
+			modify: func(c *Config) { c.Commands[0].Notice = &NoticeSpec{Channel: "general"} },
+			path:   "commands[0].notice.channel",
+		},
+		{
+			name: "invalid notice template",
+			modify: func(c *Config) {
+				c.Commands[0].Notice = &NoticeSpec{Channel: "123456789012345678", Message: "{{ .Command"}
+			},
+			path: "commands[0].notice.message",
+		},
+		{
+			name: "unknown notice field",
+			modify: func(c *Config) {
+				c.Commands[0].Notice = &NoticeSpec{Channel: "123456789012345678", Fields: []string{"titel"}}
+			},
+			path: "commands[0].notice.fields[0]",
+		},
+		{
+			name:   "hide endpoint without webhook",
+			modify: func(c *Config) { c.Commands[1].Response = &ResponseSpec{HideEndpoint: true} },
+			path:   "commands[1].response.hide_endpoint",
+		},
+		{
+			name: "webhook and webhooks",
+			modify: func(c *Config) {
+				c.Commands[0].Webhooks = []WebhookTarget{{Webhook: WebhookSpec{URL: "https://example.com/other"}}}
+			},
+			path: "commands[0].webhooks",
+		},
+		{
+			name:   "webhooks entry without url",
+			modify: func(c *Config) { c.Commands[1].Webhooks = []WebhookTarget{{Name: "tickets"}} },
+			path:   "commands[1].webhooks[0].url",
+		},
+		{
+			name: "duplicate webhooks name",
+			modify: func(c *Config) {
+				c.Commands[1].Webhooks = []WebhookTarget{
+					{Name: "tickets", Webhook: WebhookSpec{URL: "https://example.com/a"}},
+					{Name: "tickets", Webhook: WebhookSpec{URL: "https://example.com/b"}},
+				}
+			},
+			path: "commands[1].webhooks[1].name",
+		},
+		{
+			name: "invalid webhooks entry method",
+			modify: func(c *Config) {
+				c.Commands[1].Webhooks = []WebhookTarget{{Webhook: WebhookSpec{URL: "https://example.com/a", Method: "GET"}}}
+			},
+			path: "commands[1].webhooks[0].method",
+		},
+		{
+			name:   "textarea in slash command",
+			modify: func(c *Config) { c.Commands[0].Fields[0].Type = "textarea" },
+			path:   "commands[0].fields[0].type",
+		},
+		{
+			name: "max_values above option count",
+			modify: func(c *Config) {
+				maxValues := 3
+				c.Commands[1].Fields[0] = FieldSpec{Name: "rating", Type: "select", Options: []string{"1", "2"}, MaxValues: &maxValues}
+			},
+			path: "commands[1].fields[0].max_values",
+		},
+		{
+			name: "min_values greater than max_values",
+			modify: func(c *Config) {
+				minValues, maxValues := 2, 1
+				c.Commands[1].Fields[0] = FieldSpec{Name: "team", Type: "remote_select", Webhook: WebhookSpec{URL: "https://example.com/teams"}, MinValues: &minValues, MaxValues: &maxValues}
+			},
+			path: "commands[1].fields[0].min_values",
+		},
+		{
+			name: "max_values in slash command",
+			modify: func(c *Config) {
+				maxValues := 2
+				c.Commands[0].Fields[1].MaxValues = &maxValues
+			},
+			path: "commands[0].fields[1]",
+		},
+		{
+			name:   "modal without fields",
+			modify: func(c *Config) { c.Commands[1].Fields = nil },
+			path:   "commands[1].fields",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Expected *ValidationError, got %v", err)
+			}
+
+			found := false
+			for _, p := range verr.Problems {
+				if p.Path == tt.path {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Expected problem at %s, got: %v", tt.path, err)
+			}
+		})
+	}
+}
+
+func TestValidate_RetryWindowReportedOnce(t *testing.T) {
+	cfg := validConfig()
+	cfg.Commands[1].Webhook = WebhookSpec{URL: "https://example.com/feedback"}
+	cfg.Bot.Webhook.Retry = &RetryPolicy{MaxAttempts: 5, MaxDelay: 3 * time.Minute}
+
+	err := cfg.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected *ValidationError, got %v", err)
+	}
+	if len(verr.Problems) != 1 || verr.Problems[0].Path != "bot.webhook.retry" {
+		t.Errorf("Expected a single problem for the global policy, got: %v", err)
+	}
+}
+
+func TestValidate_SelectInModal(t *testing.T) {
+	cfg := validConfig()
+	maxValues := 2
+	cfg.Commands[1].Fields = append(cfg.Commands[1].Fields,
+		FieldSpec{Name: "topics", Type: "select", Options: []string{"Bug", "Idea", "Other"}, MaxValues: &maxValues},
+		FieldSpec{Name: "team", Type: "remote_select", Webhook: WebhookSpec{URL: "https://example.com/teams"}, Required: true},
+	)
+
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected select fields to be valid in a modal, got: %v", err)
+	}
+}
+
+func TestValidate_SameNameInDifferentGuilds(t *testing.T) {
+	cfg := validConfig()
+	cfg.Commands[0].Guilds = []string{"111111111111111111"}
+	cfg.Commands[1].Name = "report"
+	cfg.Commands[1].Guilds = []string{"222222222222222222"}
+
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected commands in different guilds to share a name, got: %v", err)
+	}
+}
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	cfg := validConfig()
+	cfg.Commands[0].Type = "slahs"
+	cfg.Commands[0].Fields[1].Options = nil
+	cfg.Commands[1].Name = "report"
+
+	err := cfg.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected *ValidationError, got %v", err)
+	}
+
+	if len(verr.Problems) != 3 {
+		t.Errorf("Expected 3 problems, got %d: %v", len(verr.Problems), err)
+	}
+
+	for _, path := range []string{"commands[0].type", "commands[0].fields[1].options", "commands[1].name"} {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("Expected error message to contain %s", path)
+		}
+	}
+}
+
+func TestLoadConfigInvalidConfig(t *testing.T) {
+	invalidConfig := `bot:
+  discord:
+    token: TEST_TOKEN
+
+commands:
+  - name: test-command
+    type: slahs`
+
+	tmpFile, err := os.CreateTemp("", "invalid-config-*.yml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(invalidConfig); err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	_, err = LoadConfig(tmpFile.Name())
+	if err == nil {
+		t.Fatal("Expected validation error, got nil")
+	}
+	if !strings.Contains(err.Error(), "commands[0].type") {
+		t.Errorf("Expected error to point at commands[0].type, got: %v", err)
+	}
+}
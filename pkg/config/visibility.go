@@ -0,0 +1,42 @@
+package config
+
+import "text/template"
+
+// Visibilities lists the names accepted in a command's visibility.
+var Visibilities = []string{"public", "ephemeral"}
+
+// NoticeSpec posts a public notice of each successful submission to a
+// channel, separate from the reply to the user. Only the fields it lists
+// are shown; all other values are left out.
+type NoticeSpec struct {
+	// Channel is the ID of the channel the notice is posted in.
+	Channel string `yaml:"channel"`
+	// Message is a template executed with a ResponseData whose Fields only
+	// hold the listed fields. It defaults to a line naming the command,
+	// followed by the listed fields.
+	Message string `yaml:"message,omitempty"`
+	// Fields names the fields whose values may appear in the notice.
+	Fields []string `yaml:"fields,omitempty"`
+
+	// parsed is Message as a template, filled in when the config is
+	// validated.
+	parsed *template.Template
+}
+
+// Render executes the notice's message template with data.
+func (n *NoticeSpec) Render(data ResponseData) (string, error) {
+	tmpl := n.parsed
+	if tmpl == nil {
+		var err error
+		if tmpl, err = parseTemplate("notice.message", n.Message); err != nil {
+			return "", err
+		}
+	}
+	return execute(tmpl, data)
+}
+
+// IsEphemeral reports whether replies to the command are only shown to the
+// user who ran it.
+func (c CommandSpec) IsEphemeral() bool {
+	return c.Visibility == "ephemeral"
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLoadConfigVisibilityAndNotice(t *testing.T) {
+	testConfig := `bot:
+  discord:
+    token: TEST_TOKEN
+
+commands:
+  - name: feedback
+    type: modal
+    webhook: "https://example.com/feedback"
+    visibility: ephemeral
+    notice:
+      channel: "123456789012345678"
+      message: "New feedback about {{ .Fields.topic }}"
+      fields: [topic]
+    fields:
+      - name: topic
+        type: text
+      - name: message
+        type: textarea
+  - name: report
+    type: slash
+    webhook: "https://example.com/report"
+    fields:
+      - name: title
+        type: text`
+
+	tmpFile, err := os.CreateTemp("", "test-config-*.yml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(testConfig); err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	cfg, err := LoadConfig(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	feedback := cfg.Commands[0]
+	if !feedback.IsEphemeral() {
+		t.Error("Expected feedback to be ephemeral")
+	}
+	notice := feedback.Notice
+	if notice == nil || notice.Channel != "123456789012345678" || len(notice.Fields) != 1 || notice.Fields[0] != "topic" {
+		t.Errorf("Expected notice to channel 123456789012345678 showing topic, got %+v", notice)
+	}
+
+	if cfg.Commands[1].IsEphemeral() {
+		t.Error("Expected report to be public by default")
+	}
+}
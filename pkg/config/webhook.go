@@ -0,0 +1,132 @@
+package config
+
+import (
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultWebhookTimeout bounds a webhook request without a configured timeout.
+const DefaultWebhookTimeout = 10 * time.Second
+
+// WebhookSpec describes an HTTP endpoint the bot calls. In YAML it is either
+// a plain URL or a block with the url and request settings.
+type WebhookSpec struct {
+	URL string `yaml:"url" interpolate:"env"`
+	// Method defaults to POST for command webhooks and GET for remote_select
+	// option sources.
+	Method  string            `yaml:"method,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty" interpolate:"secret"`
+	Auth    *AuthSpec         `yaml:"auth,omitempty" interpolate:"secret"`
+	// Timeout defaults to 10 seconds.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// AuthSpec adds credentials to webhook requests. Type bearer sends Token as a
+// bearer token, basic sends Username and Password, and header sends Value in
+// the Header named by Header, as used for API keys.
+type AuthSpec struct {
+	Type     string `yaml:"type"`
+	Token    string `yaml:"token,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	Header   string `yaml:"header,omitempty"`
+	Value    string `yaml:"value,omitempty"`
+}
+
+// AuthTypes lists the names accepted in an auth block's type.
+var AuthTypes = []string{"bearer", "basic", "header"}
+
+// UnmarshalYAML accepts either a URL string or a webhook block.
+func (w *WebhookSpec) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*w = WebhookSpec{}
+		return node.Decode(&w.URL)
+	}
+
+	type plain WebhookSpec
+	return node.Decode((*plain)(w))
+}
+
+// IsSet reports whether a webhook is configured.
+func (w WebhookSpec) IsSet() bool {
+	return w.URL != ""
+}
+
+// RequestTimeout returns the configured timeout, or DefaultWebhookTimeout.
+func (w WebhookSpec) RequestTimeout() time.Duration {
+	if w.Timeout > 0 {
+		return w.Timeout
+	}
+	return DefaultWebhookTimeout
+}
+
+// WebhookTarget is one of several destinations a command's submissions are
+// sent to, listed in the command's webhooks.
+type WebhookTarget struct {
+	// Name identifies the destination in responses and logs.
+	Name    string      `yaml:"name,omitempty"`
+	Webhook WebhookSpec `yaml:",inline"`
+	// PayloadTemplate, Retry and Secret override the command's settings for
+	// this destination.
+	PayloadTemplate *PayloadTemplate `yaml:"payload_template,omitempty"`
+	Retry           *RetryPolicy     `yaml:"retry,omitempty"`
+	Secret          string           `yaml:"secret,omitempty" interpolate:"secret"`
+	// Required makes a failed delivery fail the whole submission. It
+	// defaults to true.
+	Required *bool `yaml:"required,omitempty"`
+}
+
+// UnmarshalYAML accepts either a URL string or a destination block.
+func (t *WebhookTarget) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*t = WebhookTarget{}
+		return node.Decode(&t.Webhook.URL)
+	}
+
+	type plain WebhookTarget
+	return node.Decode((*plain)(t))
+}
+
+// IsRequired reports whether a failed delivery fails the submission.
+func (t WebhookTarget) IsRequired() bool {
+	return t.Required == nil || *t.Required
+}
+
+// Label returns the name of the destination, or its URL if it has none.
+func (t WebhookTarget) Label() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Webhook.URL
+}
+
+// HasWebhook reports whether the command sends its submissions anywhere.
+func (c CommandSpec) HasWebhook() bool {
+	return c.Webhook.IsSet() || len(c.Webhooks) > 0
+}
+
+// Targets returns the destinations of a command's submissions: the entries of
+// its webhooks list, or its single webhook. Destinations without their own
+// payload template or secret use the command's.
+func (c CommandSpec) Targets() []WebhookTarget {
+	if c.Webhook.IsSet() {
+		return []WebhookTarget{{
+			Webhook:         c.Webhook,
+			PayloadTemplate: c.PayloadTemplate,
+			Secret:          c.Secret,
+		}}
+	}
+
+	targets := make([]WebhookTarget, len(c.Webhooks))
+	for i, target := range c.Webhooks {
+		if target.PayloadTemplate == nil {
+			target.PayloadTemplate = c.PayloadTemplate
+		}
+		if target.Secret == "" {
+			target.Secret = c.Secret
+		}
+		targets[i] = target
+	}
+	return targets
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigWebhookBlock(t *testing.T) {
+	t.Setenv("YAMBOT_TEST_API_KEY", "from-env")
+
+	testConfig := `bot:
+  discord:
+    token: TEST_TOKEN
+
+commands:
+  - name: plain
+    type: slash
+    webhook: "https://example.com/plain"
+    fields:
+      - name: title
+        type: text
+  - name: report
+    type: slash
+    webhook:
+      url: "https://example.com/report"
+      method: PUT
+      timeout: 3s
+      headers:
+        X-Api-Key: "${YAMBOT_TEST_API_KEY}"
+      auth:
+        type: basic
+        username: bot
+        password: hunter2
+    fields:
+      - name: team
+        type: remote_select
+        webhook:
+          url: "https://example.com/teams"
+          method: POST
+          auth:
+            type: bearer
+            token: abc`
+
+	tmpFile, err := os.CreateTemp("", "test-config-*.yml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(testConfig); err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	cfg, err := LoadConfig(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	plain := cfg.Commands[0].Webhook
+	if plain.URL != "https://example.com/plain" || plain.Method != "" || plain.Auth != nil {
+		t.Errorf("Expected plain URL webhook, got %+v", plain)
+	}
+
+	webhook := cfg.Commands[1].Webhook
+	if webhook.URL != "https://example.com/report" || webhook.Method != "PUT" || webhook.Timeout != 3*time.Second {
+		t.Errorf("Expected webhook block, got %+v", webhook)
+	}
+	if webhook.Headers["X-Api-Key"] != "from-env" {
+		t.Errorf("Expected interpolated header, got '%s'", webhook.Headers["X-Api-Key"])
+	}
+	if webhook.Auth == nil || webhook.Auth.Type != "basic" || webhook.Auth.Username != "bot" || webhook.Auth.Password != "hunter2" {
+		t.Errorf("Expected basic auth, got %+v", webhook.Auth)
+	}
+
+	options := cfg.Commands[1].Fields[0].Webhook
+	if options.URL != "https://example.com/teams" || options.Method != "POST" || options.Auth == nil || options.Auth.Token != "abc" {
+		t.Errorf("Expected remote_select webhook block, got %+v", options)
+	}
+}
+
+func TestLoadConfigWebhooksList(t *testing.T) {
+	testConfig := `bot:
+  discord:
+    token: TEST_TOKEN
+
+commands:
+  - name: feedback
+    type: modal
+    secret: shared
+    payload_template: '{"text": {{ json .Fields.message }}}'
+    webhooks:
+      - name: tickets
+        url: "https://tickets.example.com/api"
+        headers:
+          X-Team: support
+        retry:
+          max_attempts: 3
+      - name: analytics
+        url: "https://analytics.example.com/collect"
+        required: false
+        secret: other
+        payload_template:
+          event: feedback
+      - "https://archive.example.com/hook"
+    fields:
+      - name: message
+        type: textarea`
+
+	tmpFile, err := os.CreateTemp("", "test-config-*.yml")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(testConfig); err != nil {
+		t.Fatalf("Failed to write to temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	cfg, err := LoadConfig(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	cmd := cfg.Commands[0]
+	if !cmd.HasWebhook() {
+		t.Error("Expected command with a webhooks list to have a webhook")
+	}
+
+	targets := cmd.Targets()
+	if len(targets) != 3 {
+		t.Fatalf("Expected 3 destinations, got %d", len(targets))
+	}
+
+	tickets, analytics, archive := targets[0], targets[1], targets[2]
+	if tickets.Label() != "tickets" || tickets.Webhook.Headers["X-Team"] != "support" || !tickets.IsRequired() {
+		t.Errorf("Expected required tickets destination with headers, got %+v", tickets)
+	}
+	if tickets.Secret != "shared" || tickets.PayloadTemplate != cmd.PayloadTemplate {
+		t.Errorf("Expected tickets to inherit the command's secret and template, got %+v", tickets)
+	}
+	if cfg.RetryForTarget(cmd, tickets).MaxAttempts != 3 {
+		t.Errorf("Expected tickets retry policy, got %+v", cfg.RetryForTarget(cmd, tickets))
+	}
+	if analytics.IsRequired() || analytics.Secret != "other" || analytics.PayloadTemplate.JSON == nil {
+		t.Errorf("Expected optional analytics destination with its own secret and template, got %+v", analytics)
+	}
+	if archive.Label() != "https://archive.example.com/hook" || !archive.IsRequired() {
+		t.Errorf("Expected plain URL destination, got %+v", archive)
+	}
+}
@@ -0,0 +1,87 @@
+package discord
+
+import (
+	"fmt"
+	"slices"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const defaultAccessDeniedMessage = "🚫 You do not have permission to use this command here."
+
+// checkAccess reports whether the user behind an interaction may run a command
+// restricted by access. When access is denied, the returned reason explains
+// why, for logging.
+func checkAccess(access *config.AccessSpec, i *discordgo.InteractionCreate) (bool, string) {
+	if access == nil {
+		return true, ""
+	}
+
+	user := interactionUser(i)
+	if user == nil {
+		return false, "interaction has no user"
+	}
+
+	var roles []string
+	if i.Member != nil {
+		roles = i.Member.Roles
+	}
+
+	if slices.Contains(access.DenyUsers, user.ID) {
+		return false, fmt.Sprintf("user %s is denied", user.ID)
+	}
+	for _, role := range roles {
+		if slices.Contains(access.DenyRoles, role) {
+			return false, fmt.Sprintf("role %s is denied", role)
+		}
+	}
+	if slices.Contains(access.DenyChannels, i.ChannelID) {
+		return false, fmt.Sprintf("channel %s is denied", i.ChannelID)
+	}
+
+	if len(access.AllowChannels) > 0 && !slices.Contains(access.AllowChannels, i.ChannelID) {
+		return false, fmt.Sprintf("channel %s is not allowed", i.ChannelID)
+	}
+
+	if len(access.AllowUsers) > 0 || len(access.AllowRoles) > 0 {
+		allowed := slices.Contains(access.AllowUsers, user.ID)
+		for _, role := range roles {
+			if slices.Contains(access.AllowRoles, role) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false, fmt.Sprintf("user %s has no allowed user ID or role", user.ID)
+		}
+	}
+
+	if mask := access.PermissionMask(); mask != nil {
+		if i.Member == nil {
+			return false, "required permissions cannot be checked outside a guild"
+		}
+		if i.Member.Permissions&*mask != *mask {
+			return false, fmt.Sprintf("user %s lacks required permissions", user.ID)
+		}
+	}
+
+	return true, ""
+}
+
+// interactionUser returns the invoking user, which Discord sends in Member for
+// guild interactions and in User for direct messages.
+func interactionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+func accessDeniedMessage(access *config.AccessSpec) string {
+	if access != nil && access.Message != "" {
+		return access.Message
+	}
+	return defaultAccessDeniedMessage
+}
@@ -0,0 +1,100 @@
+package discord
+
+import (
+	"testing"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func guildInteraction(userID, channelID string, roles []string, permissions int64) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ChannelID: channelID,
+			Member: &discordgo.Member{
+				User:        &discordgo.User{ID: userID},
+				Roles:       roles,
+				Permissions: permissions,
+			},
+		},
+	}
+}
+
+func TestCheckAccess(t *testing.T) {
+	access := &config.AccessSpec{
+		AllowRoles:    []string{"100000000000000001"},
+		AllowUsers:    []string{"200000000000000001"},
+		DenyUsers:     []string{"200000000000000002"},
+		DenyRoles:     []string{"100000000000000002"},
+		AllowChannels: []string{"300000000000000001"},
+	}
+
+	tests := []struct {
+		name        string
+		interaction *discordgo.InteractionCreate
+		allowed     bool
+	}{
+		{
+			name:        "allowed role in allowed channel",
+			interaction: guildInteraction("200000000000000009", "300000000000000001", []string{"100000000000000001"}, 0),
+			allowed:     true,
+		},
+		{
+			name:        "allowed user without roles",
+			interaction: guildInteraction("200000000000000001", "300000000000000001", nil, 0),
+			allowed:     true,
+		},
+		{
+			name:        "user without allowed role",
+			interaction: guildInteraction("200000000000000009", "300000000000000001", []string{"100000000000000009"}, 0),
+			allowed:     false,
+		},
+		{
+			name:        "denied role wins over allowed role",
+			interaction: guildInteraction("200000000000000009", "300000000000000001", []string{"100000000000000001", "100000000000000002"}, 0),
+			allowed:     false,
+		},
+		{
+			name:        "denied user",
+			interaction: guildInteraction("200000000000000002", "300000000000000001", []string{"100000000000000001"}, 0),
+			allowed:     false,
+		},
+		{
+			name:        "channel not allowed",
+			interaction: guildInteraction("200000000000000001", "300000000000000009", nil, 0),
+			allowed:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, reason := checkAccess(access, tt.interaction)
+			if allowed != tt.allowed {
+				t.Errorf("checkAccess() = %v (%s), expected %v", allowed, reason, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestCheckAccess_Permissions(t *testing.T) {
+	access := &config.AccessSpec{Permissions: []string{"manage_messages"}}
+
+	if allowed, _ := checkAccess(access, guildInteraction("1", "2", nil, discordgo.PermissionManageMessages)); !allowed {
+		t.Error("Expected member with manage_messages to be allowed")
+	}
+	if allowed, _ := checkAccess(access, guildInteraction("1", "2", nil, discordgo.PermissionSendMessages)); allowed {
+		t.Error("Expected member without manage_messages to be denied")
+	}
+
+	dm := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{User: &discordgo.User{ID: "1"}}}
+	if allowed, _ := checkAccess(access, dm); allowed {
+		t.Error("Expected permission check to deny direct messages")
+	}
+}
+
+func TestCheckAccess_NoRestrictions(t *testing.T) {
+	if allowed, _ := checkAccess(nil, guildInteraction("1", "2", nil, 0)); !allowed {
+		t.Error("Expected commands without access block to be allowed")
+	}
+}
@@ -0,0 +1,143 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// encodedPayload is a webhook payload that is already encoded, such as a
+// multipart upload.
+type encodedPayload struct {
+	body        []byte
+	contentType string
+}
+
+// attachmentFile is a file uploaded to an attachment field. Data is only set
+// once the file has been downloaded.
+type attachmentFile struct {
+	field      string
+	attachment *discordgo.MessageAttachment
+	data       []byte
+}
+
+// slashAttachments returns the files uploaded with a slash command, in the
+// order of the options.
+func slashAttachments(options []*discordgo.ApplicationCommandInteractionDataOption, resolved *discordgo.ApplicationCommandInteractionDataResolved) []attachmentFile {
+	var files []attachmentFile
+	for _, option := range options {
+		if option.Type != discordgo.ApplicationCommandOptionAttachment {
+			continue
+		}
+		if attachment, exists := resolved.Attachments[option.Value.(string)]; exists {
+			files = append(files, attachmentFile{field: option.Name, attachment: attachment})
+		}
+	}
+	return files
+}
+
+// checkAttachments reports files that are too large or of a type the command
+// does not accept, before anything is downloaded.
+func checkAttachments(spec *config.AttachmentSpec, files []attachmentFile) error {
+	var errors []string
+
+	for _, file := range files {
+		name := strings.Title(file.field)
+		if int64(file.attachment.Size) > spec.Limit() {
+			errors = append(errors, fmt.Sprintf("• **%s** is too large (%s, at most %s)", name, formatSize(int64(file.attachment.Size)), formatSize(spec.Limit())))
+		} else if !spec.Allows(file.attachment.ContentType) {
+			errors = append(errors, fmt.Sprintf("• **%s** must be one of: %s", name, strings.Join(spec.ContentTypes, ", ")))
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("Please fix the following issues:\n%s", strings.Join(errors, "\n"))
+	}
+	return nil
+}
+
+// downloadAttachments fetches the contents of every file.
+func (b *Bot) downloadAttachments(spec *config.AttachmentSpec, files []attachmentFile) error {
+	for j := range files {
+		data, err := b.WebhookService.downloadAttachment(files[j].attachment.URL, spec.Limit())
+		if err != nil {
+			log.Printf("Error downloading attachment %s: %v", files[j].attachment.Filename, err)
+			return fmt.Errorf("failed to download %s", files[j].attachment.Filename)
+		}
+		files[j].data = data
+	}
+	return nil
+}
+
+// addBase64Attachments adds the contents of every file to the field values as
+// "<field>_data".
+func addBase64Attachments(fields map[string]interface{}, files []attachmentFile) {
+	for _, file := range files {
+		fields[file.field+"_data"] = base64.StdEncoding.EncodeToString(file.data)
+	}
+}
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// multipartPayload encodes a payload as multipart/form-data: the JSON payload
+// in a "payload" part, followed by every file in a part named after its field.
+func multipartPayload(payload interface{}, files []attachmentFile) (*encodedPayload, error) {
+	body, err := marshalPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="payload"`)
+	header.Set("Content-Type", jsonContentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare webhook data")
+	}
+	part.Write(body)
+
+	for _, file := range files {
+		contentType := file.attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(file.field), quoteEscaper.Replace(file.attachment.Filename)))
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare webhook data")
+		}
+		part.Write(file.data)
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to prepare webhook data")
+	}
+
+	return &encodedPayload{body: buf.Bytes(), contentType: writer.FormDataContentType()}, nil
+}
+
+// formatSize formats a size in bytes for users.
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d bytes", size)
+	}
+}
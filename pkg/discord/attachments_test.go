@@ -0,0 +1,159 @@
+package discord
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestCheckAttachments(t *testing.T) {
+	spec := &config.AttachmentSpec{Mode: "forward", MaxSize: 1 << 20, ContentTypes: []string{"image/*"}}
+
+	tests := []struct {
+		name       string
+		attachment *discordgo.MessageAttachment
+		wantErr    string
+	}{
+		{"accepted", &discordgo.MessageAttachment{Size: 1024, ContentType: "image/png"}, ""},
+		{"too large", &discordgo.MessageAttachment{Size: 2 << 20, ContentType: "image/png"}, "too large (2.0 MB, at most 1.0 MB)"},
+		{"wrong type", &discordgo.MessageAttachment{Size: 1024, ContentType: "application/pdf"}, "must be one of: image/*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAttachments(spec, []attachmentFile{{field: "screenshot", attachment: tt.attachment}})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing '%s', got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSubmitWebhook_Attachments(t *testing.T) {
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("%PDF-1.7 test"))
+	}))
+	defer cdn.Close()
+
+	tests := []struct {
+		name  string
+		mode  string
+		check func(t *testing.T, r *http.Request)
+	}{
+		{
+			name: "forward",
+			mode: "forward",
+			check: func(t *testing.T, r *http.Request) {
+				if err := r.ParseMultipartForm(1 << 20); err != nil {
+					t.Fatalf("Expected multipart body, got %v", err)
+				}
+
+				var payload config.PayloadData
+				if err := json.Unmarshal([]byte(r.FormValue("payload")), &payload); err != nil {
+					t.Fatalf("Expected JSON payload part, got %v", err)
+				}
+				if payload.Command != "upload" || payload.Fields["file_url"] != cdn.URL+"/report.pdf" {
+					t.Errorf("Expected envelope with the field values, got %+v", payload)
+				}
+
+				file, header, err := r.FormFile("file")
+				if err != nil {
+					t.Fatalf("Expected file part, got %v", err)
+				}
+				data, _ := io.ReadAll(file)
+				if header.Filename != "report.pdf" || header.Header.Get("Content-Type") != "application/pdf" || string(data) != "%PDF-1.7 test" {
+					t.Errorf("Expected report.pdf contents, got %s (%s): %q", header.Filename, header.Header.Get("Content-Type"), data)
+				}
+			},
+		},
+		{
+			name: "base64",
+			mode: "base64",
+			check: func(t *testing.T, r *http.Request) {
+				var payload config.PayloadData
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					t.Fatalf("Expected JSON body, got %v", err)
+				}
+				if payload.Fields["file_data"] != "JVBERi0xLjcgdGVzdA==" {
+					t.Errorf("Expected base64 file contents, got %v", payload.Fields["file_data"])
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = true
+				tt.check(t, r)
+			}))
+			defer server.Close()
+
+			bot := &Bot{Config: &config.Config{}, WebhookService: NewWebhookService()}
+			cmd := &config.CommandSpec{
+				Name:        "upload",
+				Type:        "slash",
+				Webhook:     config.WebhookSpec{URL: server.URL},
+				Attachments: &config.AttachmentSpec{Mode: tt.mode},
+				Fields:      []config.FieldSpec{{Name: "file", Type: "attachment"}},
+			}
+			attachment := &discordgo.MessageAttachment{
+				ID:          "1",
+				URL:         cdn.URL + "/report.pdf",
+				Filename:    "report.pdf",
+				ContentType: "application/pdf",
+				Size:        13,
+			}
+			options := []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "file", Type: discordgo.ApplicationCommandOptionAttachment, Value: "1"},
+			}
+			resolved := &discordgo.ApplicationCommandInteractionDataResolved{
+				Attachments: map[string]*discordgo.MessageAttachment{"1": attachment},
+			}
+
+			fields := slashCommandPayload(cmd, options, resolved)
+			results := bot.submitWebhook(cmd, testInteraction(), fields, slashAttachments(options, resolved))
+			if err := results[0].err; err != nil {
+				t.Fatalf("Expected delivery to succeed, got %v", err)
+			}
+			if !received {
+				t.Error("Expected the webhook to be called")
+			}
+		})
+	}
+}
+
+func TestSubmitWebhook_DownloadLimit(t *testing.T) {
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 2048)))
+	}))
+	defer cdn.Close()
+
+	bot := &Bot{Config: &config.Config{}, WebhookService: NewWebhookService()}
+	cmd := &config.CommandSpec{
+		Name:        "upload",
+		Webhook:     config.WebhookSpec{URL: "http://127.0.0.1:1"},
+		Attachments: &config.AttachmentSpec{Mode: "forward", MaxSize: 1024},
+	}
+	// Discord reported a smaller size than the file has
+	files := []attachmentFile{{field: "file", attachment: &discordgo.MessageAttachment{URL: cdn.URL, Filename: "big.bin", Size: 10}}}
+
+	results := bot.submitWebhook(cmd, testInteraction(), map[string]interface{}{}, files)
+	if results[0].err == nil || results[0].attempts != 0 {
+		t.Errorf("Expected download to fail before delivery, got %d attempts and %v", results[0].attempts, results[0].err)
+	}
+}
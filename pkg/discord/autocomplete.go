@@ -0,0 +1,139 @@
+package discord
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Discord allows at most 25 autocomplete choices of up to 100 characters.
+	maxAutocompleteChoices = 25
+	maxChoiceLength        = 100
+
+	// autocompleteTimeout keeps the remote lookup within Discord's 3 second
+	// window for answering an autocomplete interaction.
+	autocompleteTimeout = 2500 * time.Millisecond
+)
+
+// handleAutocomplete answers autocomplete requests for remote_select fields by
+// querying the field's webhook with what the user has typed so far.
+func (b *Bot) handleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+
+	commandSpec := b.findCommandSpec(data.Name, i.GuildID)
+	if commandSpec == nil {
+		log.Printf("Autocomplete for unknown command: %s", data.Name)
+		b.respondWithChoices(s, i, nil)
+		return
+	}
+
+	if allowed, _ := checkAccess(commandSpec.Access, i); !allowed {
+		b.respondWithChoices(s, i, nil)
+		return
+	}
+
+	focused := focusedOption(data.Options)
+	if focused == nil {
+		b.respondWithChoices(s, i, nil)
+		return
+	}
+
+	field := findFieldSpec(commandSpec, focused.Name)
+	if field == nil || field.Type != "remote_select" || !field.Webhook.IsSet() {
+		b.respondWithChoices(s, i, nil)
+		return
+	}
+
+	query := focused.StringValue()
+
+	ctx, cancel := context.WithTimeout(context.Background(), autocompleteTimeout)
+	defer cancel()
+
+	options, err := b.fetchRemoteOptions(ctx, field.Webhook, query)
+	if err != nil {
+		log.Printf("Failed to fetch autocomplete options for field %s: %v", field.Name, err)
+		b.respondWithChoices(s, i, nil)
+		return
+	}
+
+	b.respondWithChoices(s, i, autocompleteChoices(options, query))
+}
+
+// autocompleteChoices keeps the options matching query, in case the endpoint
+// ignores it, and trims them to Discord's limits.
+func autocompleteChoices(options []config.RemoteOption, query string) []*discordgo.ApplicationCommandOptionChoice {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, maxAutocompleteChoices)
+	for _, option := range options {
+		if len(choices) == maxAutocompleteChoices {
+			break
+		}
+		if query != "" &&
+			!strings.Contains(strings.ToLower(option.Label), query) &&
+			!strings.Contains(strings.ToLower(option.Value), query) {
+			continue
+		}
+		// Values longer than Discord allows cannot be submitted at all
+		if utf8.RuneCountInString(option.Value) > maxChoiceLength {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  truncate(option.Label, maxChoiceLength),
+			Value: option.Value,
+		})
+	}
+
+	return choices
+}
+
+func (b *Bot) respondWithChoices(s *discordgo.Session, i *discordgo.InteractionCreate, choices []*discordgo.ApplicationCommandOptionChoice) {
+	if choices == nil {
+		choices = []*discordgo.ApplicationCommandOptionChoice{}
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+
+	if err != nil {
+		log.Printf("Error responding to autocomplete interaction: %v", err)
+	}
+}
+
+func focusedOption(options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, option := range options {
+		if option.Focused {
+			return option
+		}
+	}
+	return nil
+}
+
+func findFieldSpec(cmd *config.CommandSpec, name string) *config.FieldSpec {
+	for i := range cmd.Fields {
+		if cmd.Fields[i].Name == name {
+			return &cmd.Fields[i]
+		}
+	}
+	return nil
+}
+
+// truncate shortens s to at most max characters, marking the cut with "…".
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
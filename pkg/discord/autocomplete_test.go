@@ -0,0 +1,101 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"yambot/pkg/config"
+)
+
+func TestAutocompleteChoices(t *testing.T) {
+	options := make([]config.RemoteOption, 0, 40)
+	for i := 0; i < 40; i++ {
+		options = append(options, config.RemoteOption{Label: fmt.Sprintf("Team %d", i), Value: fmt.Sprintf("team-%d", i)})
+	}
+	options = append(options, config.RemoteOption{Label: "Finance", Value: "fin"})
+	options = append(options, config.RemoteOption{Label: strings.Repeat("x", 150), Value: "long"})
+
+	all := autocompleteChoices(options, "")
+	if len(all) != maxAutocompleteChoices {
+		t.Errorf("Expected %d choices, got %d", maxAutocompleteChoices, len(all))
+	}
+
+	filtered := autocompleteChoices(options, "FIN")
+	if len(filtered) != 1 || filtered[0].Value != "fin" {
+		t.Errorf("Expected only 'fin' to match, got %v", filtered)
+	}
+
+	long := autocompleteChoices(options, "long")
+	if len(long) != 1 || len([]rune(long[0].Name)) != maxChoiceLength {
+		t.Errorf("Expected label truncated to %d characters, got %v", maxChoiceLength, long)
+	}
+}
+
+func TestFetchRemoteOptions(t *testing.T) {
+	var receivedQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedQuery = r.URL.Query().Get("query")
+		if r.URL.Path == "/generic" {
+			fmt.Fprint(w, `[{"name": "Sales"}, {"id": 7}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"label": "Engineering", "value": "eng"}]`)
+	}))
+	defer server.Close()
+
+	bot := &Bot{}
+
+	options, err := bot.fetchRemoteOptions(context.Background(), config.WebhookSpec{URL: server.URL + "/options?team=1"}, "eng")
+	if err != nil {
+		t.Fatalf("Failed to fetch options: %v", err)
+	}
+	if receivedQuery != "eng" {
+		t.Errorf("Expected query 'eng' to be forwarded, got '%s'", receivedQuery)
+	}
+	if len(options) != 1 || options[0].Value != "eng" {
+		t.Errorf("Expected one 'eng' option, got %v", options)
+	}
+
+	options, err = bot.fetchRemoteOptions(context.Background(), config.WebhookSpec{URL: server.URL + "/generic"}, "")
+	if err != nil {
+		t.Fatalf("Failed to fetch generic options: %v", err)
+	}
+	if len(options) != 2 || options[0].Value != "Sales" || options[1].Value != "7" {
+		t.Errorf("Expected options 'Sales' and '7', got %v", options)
+	}
+}
+
+func TestFetchRemoteOptions_Post(t *testing.T) {
+	var method, auth string
+	var body map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `[{"label": "Engineering", "value": "eng"}]`)
+	}))
+	defer server.Close()
+
+	bot := &Bot{}
+
+	webhook := config.WebhookSpec{
+		URL:    server.URL,
+		Method: "POST",
+		Auth:   &config.AuthSpec{Type: "bearer", Token: "abc"},
+	}
+	if _, err := bot.fetchRemoteOptions(context.Background(), webhook, "eng"); err != nil {
+		t.Fatalf("Failed to fetch options: %v", err)
+	}
+
+	if method != "POST" || auth != "Bearer abc" {
+		t.Errorf("Expected authenticated POST, got %s with '%s'", method, auth)
+	}
+	if body["query"] != "eng" {
+		t.Errorf("Expected query 'eng' in the body, got %v", body)
+	}
+}
@@ -1,16 +1,21 @@
 package discord
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"yambot/pkg/config"
+	"yambot/pkg/outbox"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -19,6 +24,24 @@ type Bot struct {
 	Session        *discordgo.Session
 	Config         *config.Config
 	WebhookService *WebhookService
+
+	// ConfigPath enables hot reload when set: the file is watched for changes
+	// and re-read on SIGHUP.
+	ConfigPath string
+
+	// Outbox, when set, stores every webhook payload before delivery and
+	// keeps failed ones for redelivery in the background.
+	Outbox *outbox.Store
+
+	configMu sync.RWMutex
+	reloadMu sync.Mutex
+
+	guildsMu     sync.Mutex
+	syncedGuilds map[string]bool
+	sweptGuilds  map[string]bool
+
+	wizardMu sync.Mutex
+	wizards  map[string]*wizardSession
 }
 
 func NewBot(cfg *config.Config) (*Bot, error) {
@@ -36,12 +59,25 @@ func NewBot(cfg *config.Config) (*Bot, error) {
 		Session:        session,
 		Config:         cfg,
 		WebhookService: NewWebhookService(),
+		syncedGuilds:   make(map[string]bool),
+		sweptGuilds:    make(map[string]bool),
+		wizards:        make(map[string]*wizardSession),
 	}, nil
 }
 
+// currentConfig returns the active configuration. It must be used instead of
+// reading b.Config directly, since a reload may swap it at any time.
+func (b *Bot) currentConfig() *config.Config {
+	b.configMu.RLock()
+	defer b.configMu.RUnlock()
+	return b.Config
+}
+
 func (b *Bot) Start() error {
 	b.Session.AddHandler(b.handleInteraction)
 	b.Session.AddHandler(b.handleModalSubmit)
+	b.Session.AddHandler(b.handleRawModalSubmit)
+	b.Session.AddHandler(b.handleGuildCreate)
 
 	err := b.Session.Open()
 	if err != nil {
@@ -55,16 +91,43 @@ func (b *Bot) Start() error {
 		return fmt.Errorf("failed to register commands: %w", err)
 	}
 
+	done := make(chan struct{})
+	defer close(done)
+
+	hangup := make(chan os.Signal, 1)
+	if b.ConfigPath != "" {
+		signal.Notify(hangup, syscall.SIGHUP)
+		go b.watchConfig(done)
+	}
+
+	if b.Outbox != nil {
+		go b.outboxWorker().Run(done)
+	}
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
-	<-stop
 
-	log.Println("Shutting down bot...")
-	return b.Session.Close()
+	for {
+		select {
+		case <-hangup:
+			log.Println("Received SIGHUP, reloading config...")
+			b.reloadConfig()
+		case <-stop:
+			log.Println("Shutting down bot...")
+			return b.Session.Close()
+		}
+	}
 }
 
 func (b *Bot) handleInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	b.dispatchCommand(s, i)
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		b.dispatchCommand(s, i)
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		b.handleAutocomplete(s, i)
+	case discordgo.InteractionMessageComponent:
+		b.handleComponent(s, i)
+	}
 }
 
 func (b *Bot) dispatchCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -81,13 +144,19 @@ func (b *Bot) dispatchCommand(s *discordgo.Session, i *discordgo.InteractionCrea
 	commandName := i.ApplicationCommandData().Name
 	log.Printf("Dispatching command: %s", commandName)
 
-	commandSpec := b.findCommandSpec(commandName)
+	commandSpec := b.findCommandSpec(commandName, i.GuildID)
 	if commandSpec == nil {
 		log.Printf("Unknown command: %s", commandName)
 		b.respondWithError(s, i, "Error: Unknown command")
 		return
 	}
 
+	if allowed, reason := checkAccess(commandSpec.Access, i); !allowed {
+		log.Printf("Access denied to command %s: %s", commandName, reason)
+		b.respondWithError(s, i, accessDeniedMessage(commandSpec.Access))
+		return
+	}
+
 	log.Printf("Found command spec for %s (type: %s)", commandName, commandSpec.Type)
 
 	err := b.routeToHandler(s, i, commandSpec)
@@ -97,13 +166,26 @@ func (b *Bot) dispatchCommand(s *discordgo.Session, i *discordgo.InteractionCrea
 	}
 }
 
-func (b *Bot) findCommandSpec(commandName string) *config.CommandSpec {
-	for _, cmd := range b.Config.GetCommands() {
-		if cmd.Name == commandName {
-			return &cmd
+// findCommandSpec looks up a command by name among the commands registered in
+// the given guild, preferring a guild-scoped command over a global one.
+func (b *Bot) findCommandSpec(commandName, guildID string) *config.CommandSpec {
+	cfg := b.currentConfig()
+
+	var global *config.CommandSpec
+	for _, cmd := range cfg.GetCommands() {
+		if cmd.Name != commandName {
+			continue
+		}
+		for _, scope := range cfg.GuildsFor(cmd) {
+			if scope == guildID && guildID != "" {
+				return &cmd
+			}
+			if scope == "" && global == nil {
+				global = &cmd
+			}
 		}
 	}
-	return nil
+	return global
 }
 
 func (b *Bot) routeToHandler(s *discordgo.Session, i *discordgo.InteractionCreate, commandSpec *config.CommandSpec) error {
@@ -120,68 +202,162 @@ func (b *Bot) routeToHandler(s *discordgo.Session, i *discordgo.InteractionCreat
 func (b *Bot) handleSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate, cmd *config.CommandSpec) error {
 	options := i.ApplicationCommandData().Options
 
+	// Resolved data might be nil when no option references an entity
+	resolved := i.ApplicationCommandData().Resolved
+	if resolved == nil {
+		resolved = &discordgo.ApplicationCommandInteractionDataResolved{}
+	}
+	display := slashDisplayValues(options, resolved)
+
+	if err := b.validateSlashOptions(cmd, options); err != nil {
+		b.respondWithError(s, i, validationResponse(cmd, i, display, err))
+		return nil
+	}
+
+	files := slashAttachments(options, resolved)
+	if cmd.Attachments.Downloads() {
+		if err := checkAttachments(cmd.Attachments, files); err != nil {
+			b.respondWithError(s, i, validationResponse(cmd, i, display, err))
+			return nil
+		}
+	}
+
+	// The webhook may take longer than Discord's 3 second window, so the
+	// response is deferred and edited once the webhook has answered.
+	if err := b.deferResponse(s, i, cmd.IsEphemeral()); err != nil {
+		return fmt.Errorf("error deferring slash command response: %w", err)
+	}
+
+	// remote_select options use autocomplete, which lets users type any text,
+	// so their values are checked against the remote list like modal values
+	if err := b.validateRemoteValues(cmd, slashOptionValues(options)); err != nil {
+		b.failDeferred(s, i, validationResponse(cmd, i, display, err))
+		return nil
+	}
+
+	var results []deliveryResult
+	if cmd.HasWebhook() {
+		results = b.submitWebhook(cmd, i, slashCommandPayload(cmd, options, resolved), files)
+	}
+	if !submissionFailed(results) {
+		b.postNotice(s, cmd, i, display, results)
+	}
+	if reply := replyFrom(results); reply != nil {
+		b.sendReply(s, i, reply, cmd.IsEphemeral())
+		return nil
+	}
+
+	if cmd.Response.UsesEmbed() {
+		b.editEmbed(s, i, responseEmbed(cmd, i, display, results, time.Now()))
+		return nil
+	}
+
+	response, ok := customResponse(cmd, i, display, results)
+	if !ok {
+		response = slashCommandResponse(cmd, options, display, results)
+	}
+
+	// Echoed user, role and channel mentions must not ping anyone, which
+	// editResponse takes care of
+	b.editResponse(s, i, response, nil)
+
+	return nil
+}
+
+// slashCommandResponse is the default reply to a slash command: the submitted
+// values, unless hidden, followed by the webhook status.
+func slashCommandResponse(cmd *config.CommandSpec, options []*discordgo.ApplicationCommandInteractionDataOption, display map[string]string, results []deliveryResult) string {
 	response := fmt.Sprintf("Received slash command: %s\n", cmd.Name)
 
-	if len(options) > 0 {
+	if len(options) > 0 && !cmd.Response.HidesFields() {
 		response += "\nSubmitted data:"
 		for _, option := range options {
-			switch option.Type {
-			case discordgo.ApplicationCommandOptionString:
-				response += fmt.Sprintf("\n**%s**: %s", option.Name, option.StringValue())
-			case discordgo.ApplicationCommandOptionAttachment:
-				attachmentID := option.Value.(string)
-				if attachment, exists := i.ApplicationCommandData().Resolved.Attachments[attachmentID]; exists {
-					response += fmt.Sprintf("\n**%s**: %s (%s, %d bytes)", option.Name, attachment.Filename, attachment.ContentType, attachment.Size)
-				} else {
-					response += fmt.Sprintf("\n**%s**: %s", option.Name, attachmentID)
-				}
-			default:
-				response += fmt.Sprintf("\n**%s**: %v", option.Name, option.Value)
+			response += fmt.Sprintf("\n**%s**: %s", option.Name, display[option.Name])
+		}
+		for _, field := range cmd.Fields {
+			if _, submitted := display[field.Name]; !submitted {
+				response += fmt.Sprintf("\n**%s**: *Not provided*", field.Name)
 			}
 		}
 	}
 
-	var webhookError error
-	if cmd.Webhook != "" {
-		// Safely handle attachments - they might be nil
-		var attachments map[string]*discordgo.MessageAttachment
-		if i.ApplicationCommandData().Resolved != nil {
-			attachments = i.ApplicationCommandData().Resolved.Attachments
-		}
-		if attachments == nil {
-			attachments = make(map[string]*discordgo.MessageAttachment)
+	return response + webhookStatuses(results, cmd.Response.HidesEndpoint())
+}
+
+// slashOptionValues returns the values of a slash command's string options by
+// name, in the form the form validation takes.
+func slashOptionValues(options []*discordgo.ApplicationCommandInteractionDataOption) map[string]interface{} {
+	values := make(map[string]interface{}, len(options))
+	for _, option := range options {
+		if option.Type == discordgo.ApplicationCommandOptionString {
+			values[option.Name] = option.StringValue()
 		}
+	}
+	return values
+}
 
-		webhookError = b.WebhookService.SendSlashCommandWebhook(cmd.Webhook, cmd.Name, options, attachments)
-		if webhookError != nil {
-			response += fmt.Sprintf("\n\n❌ **Webhook Status**: Failed to send data\n🌐 **Endpoint**: %s\n⚠️ **Error**: %s", cmd.Webhook, webhookError.Error())
-		} else {
-			response += fmt.Sprintf("\n\n✅ **Webhook Status**: Data sent successfully\n🌐 **Endpoint**: %s", cmd.Webhook)
+// slashDisplayValues returns the submitted option values as shown to the
+// user. Users, roles and channels are shown as mentions.
+func slashDisplayValues(options []*discordgo.ApplicationCommandInteractionDataOption, resolved *discordgo.ApplicationCommandInteractionDataResolved) map[string]string {
+	display := make(map[string]string, len(options))
+	for _, option := range options {
+		switch option.Type {
+		case discordgo.ApplicationCommandOptionString:
+			display[option.Name] = option.StringValue()
+		case discordgo.ApplicationCommandOptionAttachment:
+			attachmentID := option.Value.(string)
+			if attachment, exists := resolved.Attachments[attachmentID]; exists {
+				display[option.Name] = fmt.Sprintf("%s (%s, %d bytes)", attachment.Filename, attachment.ContentType, attachment.Size)
+			} else {
+				display[option.Name] = attachmentID
+			}
+		case discordgo.ApplicationCommandOptionUser:
+			display[option.Name] = fmt.Sprintf("<@%s>", option.Value)
+		case discordgo.ApplicationCommandOptionRole:
+			display[option.Name] = fmt.Sprintf("<@&%s>", option.Value)
+		case discordgo.ApplicationCommandOptionChannel:
+			display[option.Name] = fmt.Sprintf("<#%s>", option.Value)
+		case discordgo.ApplicationCommandOptionMentionable:
+			if _, isRole := resolved.Roles[option.Value.(string)]; isRole {
+				display[option.Name] = fmt.Sprintf("<@&%s>", option.Value)
+			} else {
+				display[option.Name] = fmt.Sprintf("<@%s>", option.Value)
+			}
+		default:
+			display[option.Name] = fmt.Sprint(option.Value)
 		}
 	}
+	return display
+}
 
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: response,
-		},
-	})
+// handleModalCommand opens the modal form of a command. Forms with more
+// fields than fit in one modal are split into pages, which are filled in one
+// after the other.
+func (b *Bot) handleModalCommand(s *discordgo.Session, i *discordgo.InteractionCreate, cmd *config.CommandSpec) error {
+	pages := modalPages(cmd.Fields)
 
-	if err != nil {
-		return fmt.Errorf("error responding to slash command interaction: %w", err)
+	customID := fmt.Sprintf("modal_%s", cmd.Name)
+	if len(pages) > 1 {
+		customID = wizardModalID(cmd.Name, b.startWizard(i, cmd), 0)
 	}
 
-	return nil
+	return b.respondWithModalPage(s, i, cmd, customID, 0, pages)
 }
 
-func (b *Bot) handleModalCommand(s *discordgo.Session, i *discordgo.InteractionCreate, cmd *config.CommandSpec) error {
-	components := b.createModalComponents(cmd)
+func (b *Bot) respondWithModalPage(s *discordgo.Session, i *discordgo.InteractionCreate, cmd *config.CommandSpec, customID string, page int, pages [][]config.FieldSpec) error {
+	pageSpec := *cmd
+	pageSpec.Fields = pages[page]
+
+	components, err := b.createModalComponents(&pageSpec)
+	if err != nil {
+		return fmt.Errorf("error creating modal components: %w", err)
+	}
 
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseModal,
 		Data: &discordgo.InteractionResponseData{
-			CustomID:   fmt.Sprintf("modal_%s", cmd.Name),
-			Title:      fmt.Sprintf("Form: %s", cmd.Name),
+			CustomID:   customID,
+			Title:      modalTitle(cmd.Name, page, len(pages)),
 			Components: components,
 		},
 	})
@@ -193,18 +369,36 @@ func (b *Bot) handleModalCommand(s *discordgo.Session, i *discordgo.InteractionC
 	return nil
 }
 
-func (b *Bot) fetchRemoteOptions(webhookURL string) ([]config.RemoteOption, error) {
+// fetchRemoteOptions loads the options of a remote_select field. A non-empty
+// query is passed to the endpoint so it can filter the options by what the
+// user typed so far: as the "query" URL parameter for GET requests, and as
+// {"query": ...} in the body for POST requests.
+func (b *Bot) fetchRemoteOptions(ctx context.Context, webhook config.WebhookSpec, query string) ([]config.RemoteOption, error) {
+	webhookURL := webhook.URL
 	client := &http.Client{
-		Timeout: 10 * time.Second,
+		Timeout: webhook.RequestTimeout(),
 	}
 
-	req, err := http.NewRequest("GET", webhookURL, nil)
+	requestURL, err := url.Parse(webhookURL)
 	if err != nil {
-		log.Printf("Error creating request for remote options: %v", err)
+		log.Printf("Error parsing remote options URL %s: %v", webhookURL, err)
 		return nil, fmt.Errorf("failed to create request")
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	var payload []byte
+	if webhook.Method == "POST" {
+		payload, _ = json.Marshal(map[string]string{"query": query})
+	} else if query != "" {
+		values := requestURL.Query()
+		values.Set("query", query)
+		requestURL.RawQuery = values.Encode()
+	}
+
+	req, err := newWebhookRequest(ctx, webhook, requestURL.String(), "GET", payload, jsonContentType)
+	if err != nil {
+		log.Printf("Error creating request for remote options: %v", err)
+		return nil, fmt.Errorf("failed to create request")
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -218,54 +412,72 @@ func (b *Bot) fetchRemoteOptions(webhookURL string) ([]config.RemoteOption, erro
 		return nil, fmt.Errorf("webhook returned status %d", resp.StatusCode)
 	}
 
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("Error reading remote options from %s: %v", webhookURL, err)
+		return nil, fmt.Errorf("failed to read response")
+	}
+
+	options, err := decodeRemoteOptions(body)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Successfully fetched %d remote options from %s", len(options), webhookURL)
+	return options, nil
+}
+
+// decodeRemoteOptions accepts either a list of {"label", "value"} objects or
+// a list of arbitrary objects with a name, title or id.
+func decodeRemoteOptions(body []byte) ([]config.RemoteOption, error) {
 	var options []config.RemoteOption
 
 	// Try to decode as expected format first
-	if err := json.NewDecoder(resp.Body).Decode(&options); err != nil {
-		log.Printf("Failed to decode as RemoteOption array, trying alternative formats: %v", err)
-
-		// Reset response body for another attempt
-		resp.Body.Close()
-		resp, err = client.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("failed to re-fetch remote options")
-		}
-		defer resp.Body.Close()
+	if err := json.Unmarshal(body, &options); err == nil && remoteOptionsComplete(options) {
+		return options, nil
+	}
 
-		// Try to decode as generic array of objects
-		var genericOptions []map[string]interface{}
-		if err := json.NewDecoder(resp.Body).Decode(&genericOptions); err != nil {
-			log.Printf("Failed to decode as generic array: %v", err)
-			return nil, fmt.Errorf("failed to decode response")
-		}
+	// Try to decode as generic array of objects
+	var genericOptions []map[string]interface{}
+	if err := json.Unmarshal(body, &genericOptions); err != nil {
+		log.Printf("Failed to decode as generic array: %v", err)
+		return nil, fmt.Errorf("failed to decode response")
+	}
 
-		// Convert to RemoteOption format
-		options = make([]config.RemoteOption, 0, len(genericOptions))
-		for _, item := range genericOptions {
-			label := ""
-			value := ""
-
-			// Try common field names
-			if name, ok := item["name"].(string); ok {
-				label = name
-				value = name
-			} else if title, ok := item["title"].(string); ok {
-				label = title
-				value = title
-			} else if id, ok := item["id"]; ok {
-				label = fmt.Sprintf("ID: %v", id)
-				value = fmt.Sprintf("%v", id)
-			}
+	// Convert to RemoteOption format
+	options = make([]config.RemoteOption, 0, len(genericOptions))
+	for _, item := range genericOptions {
+		label := ""
+		value := ""
+
+		// Try common field names
+		if name, ok := item["name"].(string); ok {
+			label = name
+			value = name
+		} else if title, ok := item["title"].(string); ok {
+			label = title
+			value = title
+		} else if id, ok := item["id"]; ok {
+			label = fmt.Sprintf("ID: %v", id)
+			value = fmt.Sprintf("%v", id)
+		}
 
-			if label != "" && value != "" {
-				options = append(options, config.RemoteOption{
-					Label: label,
-					Value: value,
-				})
-			}
+		if label != "" && value != "" {
+			options = append(options, config.RemoteOption{
+				Label: label,
+				Value: value,
+			})
 		}
 	}
 
-	log.Printf("Successfully fetched %d remote options from %s", len(options), webhookURL)
 	return options, nil
 }
+
+func remoteOptionsComplete(options []config.RemoteOption) bool {
+	for _, option := range options {
+		if option.Label == "" || option.Value == "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package discord
+
+import (
+	"testing"
+
+	"yambot/pkg/config"
+)
+
+func TestFindCommandSpec_GuildRouting(t *testing.T) {
+	bot := &Bot{
+		Config: &config.Config{
+			Commands: []config.CommandSpec{
+				{Name: "report", Type: "slash", Webhook: config.WebhookSpec{URL: "https://example.com/global"}},
+				{Name: "report", Type: "slash", Webhook: config.WebhookSpec{URL: "https://example.com/staging"}, Guilds: []string{"222222222222222222"}},
+				{Name: "deploy", Type: "slash", Guilds: []string{"111111111111111111"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		command     string
+		guildID     string
+		wantWebhook string
+		wantNil     bool
+	}{
+		{name: "global command in DM", command: "report", guildID: "", wantWebhook: "https://example.com/global"},
+		{name: "global command in other guild", command: "report", guildID: "333333333333333333", wantWebhook: "https://example.com/global"},
+		{name: "guild command overrides global", command: "report", guildID: "222222222222222222", wantWebhook: "https://example.com/staging"},
+		{name: "guild command in its guild", command: "deploy", guildID: "111111111111111111"},
+		{name: "guild command in other guild", command: "deploy", guildID: "222222222222222222", wantNil: true},
+		{name: "unknown command", command: "missing", guildID: "", wantNil: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := bot.findCommandSpec(tt.command, tt.guildID)
+			if tt.wantNil {
+				if cmd != nil {
+					t.Errorf("Expected no command, got %+v", cmd)
+				}
+				return
+			}
+			if cmd == nil {
+				t.Fatal("Expected a command, got nil")
+			}
+			if cmd.Webhook.URL != tt.wantWebhook {
+				t.Errorf("Expected webhook '%s', got '%s'", tt.wantWebhook, cmd.Webhook.URL)
+			}
+		})
+	}
+}
@@ -1,80 +1,237 @@
 package discord
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 
 	"github.com/bwmarrin/discordgo"
 	"yambot/pkg/config"
 )
 
+// registerCommands syncs the configured commands at startup. This is the
+// only time every guild the bot is in is checked for stale commands, since
+// commands registered there before a restart are not remembered; guilds
+// joined later are checked once by handleGuildCreate.
 func (b *Bot) registerCommands() error {
-	log.Printf("Registering %d commands...", len(b.Config.GetCommands()))
+	return b.syncCommands(b.currentConfig(), b.sessionGuilds())
+}
+
+// syncCommands makes the commands registered in Discord match the configured
+// ones, separately for the global scope and for every guild a command is
+// registered in. Guilds synced earlier but no longer configured are synced
+// with an empty list so their stale commands are removed, as are the joined
+// guilds that have not been checked yet. Reloads pass no joined guilds, so
+// only the configured and previously synced guilds are listed again.
+func (b *Bot) syncCommands(cfg *config.Config, joined []string) error {
+	b.guildsMu.Lock()
+	defer b.guildsMu.Unlock()
+
+	byScope := commandScopes(cfg, b.syncedGuilds, b.claimGuilds(joined))
+
+	var errs []error
+	for guildID, commands := range byScope {
+		if guildID != "" {
+			b.sweptGuilds[guildID] = true
+		}
+		if err := b.syncScope(guildID, commands); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if guildID == "" {
+			continue
+		}
+		if len(commands) > 0 {
+			b.syncedGuilds[guildID] = true
+		} else {
+			delete(b.syncedGuilds, guildID)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// handleGuildCreate checks a guild the first time the bot sees it after
+// startup, either because it joined the guild or because the guild became
+// available late, and removes the commands no longer configured there.
+// Guilds already checked are skipped, so outages that resend GuildCreate
+// cost no API calls.
+func (b *Bot) handleGuildCreate(s *discordgo.Session, g *discordgo.GuildCreate) {
+	b.guildsMu.Lock()
+	defer b.guildsMu.Unlock()
+
+	if len(b.claimGuilds([]string{g.ID})) == 0 {
+		return
+	}
+
+	commands := commandScopes(b.currentConfig(), nil, []string{g.ID})[g.ID]
+	if err := b.syncScope(g.ID, commands); err != nil {
+		log.Printf("Failed to sync commands for new guild %s: %v", g.ID, err)
+		return
+	}
+	if len(commands) > 0 {
+		b.syncedGuilds[g.ID] = true
+	}
+}
+
+// claimGuilds returns the guilds that have not been checked for stale
+// commands yet and marks them as checked. The caller must hold guildsMu.
+func (b *Bot) claimGuilds(guildIDs []string) []string {
+	var unchecked []string
+	for _, guildID := range guildIDs {
+		if b.sweptGuilds[guildID] {
+			continue
+		}
+		b.sweptGuilds[guildID] = true
+		unchecked = append(unchecked, guildID)
+	}
+	return unchecked
+}
 
-	for _, cmd := range b.Config.GetCommands() {
-		if err := b.registerCommand(cmd); err != nil {
-			return fmt.Errorf("failed to register command %s: %w", cmd.Name, err)
+// commandScopes groups the configured commands by the scope they are
+// registered in: "" for global commands, otherwise a guild ID. The global
+// scope, the synced guilds and the joined guilds are always included, with no
+// commands unless some are configured for them.
+func commandScopes(cfg *config.Config, synced map[string]bool, joined []string) map[string][]config.CommandSpec {
+	byScope := map[string][]config.CommandSpec{"": nil}
+	for guildID := range synced {
+		byScope[guildID] = nil
+	}
+	for _, guildID := range joined {
+		byScope[guildID] = nil
+	}
+	for _, cmd := range cfg.GetCommands() {
+		for _, guildID := range cfg.GuildsFor(cmd) {
+			byScope[guildID] = append(byScope[guildID], cmd)
 		}
-		log.Printf("Registered command: %s (type: %s)", cmd.Name, cmd.Type)
+	}
+	return byScope
+}
+
+// sessionGuilds returns the IDs of the guilds the bot is in, as received
+// with the Ready event.
+func (b *Bot) sessionGuilds() []string {
+	if b.Session == nil || b.Session.State == nil {
+		return nil
 	}
 
+	state := b.Session.State
+	state.RLock()
+	defer state.RUnlock()
+
+	guildIDs := make([]string, 0, len(state.Guilds))
+	for _, guild := range state.Guilds {
+		guildIDs = append(guildIDs, guild.ID)
+	}
+	return guildIDs
+}
+
+// syncScope syncs the commands of one scope: the global commands when
+// guildID is empty, otherwise the commands of that guild. It compares the
+// configured and registered sets, logs the create/update/delete diff and,
+// only when something differs, replaces the registered commands with a
+// single bulk overwrite, which also removes commands that are no longer
+// configured.
+func (b *Bot) syncScope(guildID string, commands []config.CommandSpec) error {
+	appID := b.Session.State.User.ID
+	scope := scopeName(guildID)
+
+	desired := make([]*discordgo.ApplicationCommand, 0, len(commands))
+	for _, cmd := range commands {
+		command, err := b.buildApplicationCommand(cmd)
+		if err != nil {
+			return fmt.Errorf("failed to build command %s: %w", cmd.Name, err)
+		}
+		desired = append(desired, command)
+	}
+
+	registered, err := b.Session.ApplicationCommands(appID, guildID)
+	if err != nil {
+		return fmt.Errorf("failed to list registered %s commands: %w", scope, err)
+	}
+
+	diff := diffApplicationCommands(registered, desired)
+	if diff.empty() {
+		log.Printf("All %d %s commands are already in sync", len(desired), scope)
+		return nil
+	}
+
+	for _, name := range diff.create {
+		log.Printf("Command sync (%s): create %s", scope, name)
+	}
+	for _, name := range diff.update {
+		log.Printf("Command sync (%s): update %s", scope, name)
+	}
+	for _, name := range diff.delete {
+		log.Printf("Command sync (%s): delete %s", scope, name)
+	}
+
+	if _, err := b.Session.ApplicationCommandBulkOverwrite(appID, guildID, desired); err != nil {
+		return fmt.Errorf("failed to overwrite %s commands: %w", scope, err)
+	}
+
+	log.Printf("Synced %d %s commands (%d created, %d updated, %d deleted)",
+		len(desired), scope, len(diff.create), len(diff.update), len(diff.delete))
 	return nil
 }
 
-func (b *Bot) registerCommand(cmd config.CommandSpec) error {
+func scopeName(guildID string) string {
+	if guildID == "" {
+		return "global"
+	}
+	return "guild " + guildID
+}
+
+func (b *Bot) buildApplicationCommand(cmd config.CommandSpec) (*discordgo.ApplicationCommand, error) {
 	switch cmd.Type {
 	case "slash":
-		return b.registerSlashCommand(cmd)
+		return b.buildSlashCommand(cmd)
 	case "modal":
-		return b.registerModalCommand(cmd)
+		return b.buildModalCommand(cmd), nil
 	default:
-		return fmt.Errorf("unknown command type: %s", cmd.Type)
+		return nil, fmt.Errorf("unknown command type: %s", cmd.Type)
 	}
 }
 
-func (b *Bot) registerSlashCommand(cmd config.CommandSpec) error {
+func (b *Bot) buildSlashCommand(cmd config.CommandSpec) (*discordgo.ApplicationCommand, error) {
 	options := make([]*discordgo.ApplicationCommandOption, 0)
 
 	for _, field := range cmd.Fields {
 		option, err := b.createCommandOption(field)
 		if err != nil {
-			return fmt.Errorf("failed to create option for field %s: %w", field.Name, err)
+			return nil, fmt.Errorf("failed to create option for field %s: %w", field.Name, err)
 		}
 		options = append(options, option)
 	}
 
-	command := &discordgo.ApplicationCommand{
-		Name:        cmd.Name,
-		Description: fmt.Sprintf("Execute %s command", cmd.Name),
-		Options:     options,
-	}
+	// Discord rejects commands whose required options follow optional ones,
+	// so move required options first while keeping the configured order.
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].Required && !options[j].Required
+	})
 
-	_, err := b.Session.ApplicationCommandCreate(b.Session.State.User.ID, "", command)
-	if err != nil {
-		return fmt.Errorf("failed to create slash command: %w", err)
-	}
-
-	return nil
+	return &discordgo.ApplicationCommand{
+		Name:                     cmd.Name,
+		Description:              fmt.Sprintf("Execute %s command", cmd.Name),
+		Options:                  options,
+		DefaultMemberPermissions: cmd.Access.DefaultMemberPermissions(),
+	}, nil
 }
 
-func (b *Bot) registerModalCommand(cmd config.CommandSpec) error {
-	command := &discordgo.ApplicationCommand{
-		Name:        cmd.Name,
-		Description: fmt.Sprintf("Open %s form", cmd.Name),
-		Options:     []*discordgo.ApplicationCommandOption{},
+func (b *Bot) buildModalCommand(cmd config.CommandSpec) *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:                     cmd.Name,
+		Description:              fmt.Sprintf("Open %s form", cmd.Name),
+		Options:                  []*discordgo.ApplicationCommandOption{},
+		DefaultMemberPermissions: cmd.Access.DefaultMemberPermissions(),
 	}
-
-	_, err := b.Session.ApplicationCommandCreate(b.Session.State.User.ID, "", command)
-	if err != nil {
-		return fmt.Errorf("failed to create modal command: %w", err)
-	}
-
-	return nil
 }
 
 func (b *Bot) createCommandOption(field config.FieldSpec) (*discordgo.ApplicationCommandOption, error) {
 	var optionType discordgo.ApplicationCommandOptionType
 	var choices []*discordgo.ApplicationCommandOptionChoice
+	var autocomplete bool
 
 	switch field.Type {
 	case "text":
@@ -91,32 +248,65 @@ func (b *Bot) createCommandOption(field config.FieldSpec) (*discordgo.Applicatio
 			}
 		}
 	case "remote_select":
+		// Options are looked up while the user types, see handleAutocomplete
 		optionType = discordgo.ApplicationCommandOptionString
-		if field.Webhook != "" {
-			remoteOptions, err := b.fetchRemoteOptions(field.Webhook)
-			if err != nil {
-				log.Printf("Failed to fetch remote options for field %s: %v", field.Name, err)
-				return nil, fmt.Errorf("failed to fetch remote options: %w", err)
-			}
-			choices = make([]*discordgo.ApplicationCommandOptionChoice, 0)
-			for _, option := range remoteOptions {
-				choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
-					Name:  option.Label,
-					Value: option.Value,
-				})
-			}
-		}
+		autocomplete = true
 	case "attachment":
 		optionType = discordgo.ApplicationCommandOptionAttachment
+	case "integer":
+		optionType = discordgo.ApplicationCommandOptionInteger
+	case "number":
+		optionType = discordgo.ApplicationCommandOptionNumber
+	case "boolean":
+		optionType = discordgo.ApplicationCommandOptionBoolean
+	case "user":
+		optionType = discordgo.ApplicationCommandOptionUser
+	case "channel":
+		optionType = discordgo.ApplicationCommandOptionChannel
+	case "role":
+		optionType = discordgo.ApplicationCommandOptionRole
+	case "mentionable":
+		optionType = discordgo.ApplicationCommandOptionMentionable
 	default:
 		return nil, fmt.Errorf("unsupported field type: %s", field.Type)
 	}
 
-	return &discordgo.ApplicationCommandOption{
-		Type:        optionType,
-		Name:        field.Name,
-		Description: fmt.Sprintf("Enter %s", field.Name),
-		Required:    true,
-		Choices:     choices,
-	}, nil
+	option := &discordgo.ApplicationCommandOption{
+		Type:         optionType,
+		Name:         field.Name,
+		Description:  fmt.Sprintf("Enter %s", field.Name),
+		Required:     field.Required,
+		Choices:      choices,
+		Autocomplete: autocomplete,
+		MinValue:     field.MinValue,
+		MinLength:    field.MinLength,
+	}
+	if field.MaxValue != nil {
+		option.MaxValue = *field.MaxValue
+	}
+	if field.MaxLength != nil {
+		option.MaxLength = *field.MaxLength
+	}
+	for _, name := range field.ChannelTypes {
+		option.ChannelTypes = append(option.ChannelTypes, channelTypes[name])
+	}
+
+	return option, nil
+}
+
+// channelTypes maps the names accepted in channel_types to Discord's types.
+var channelTypes = map[string]discordgo.ChannelType{
+	"text":                discordgo.ChannelTypeGuildText,
+	"dm":                  discordgo.ChannelTypeDM,
+	"voice":               discordgo.ChannelTypeGuildVoice,
+	"group_dm":            discordgo.ChannelTypeGroupDM,
+	"category":            discordgo.ChannelTypeGuildCategory,
+	"announcement":        discordgo.ChannelTypeGuildNews,
+	"announcement_thread": discordgo.ChannelTypeGuildNewsThread,
+	"public_thread":       discordgo.ChannelTypeGuildPublicThread,
+	"private_thread":      discordgo.ChannelTypeGuildPrivateThread,
+	"stage":               discordgo.ChannelTypeGuildStageVoice,
+	"directory":           discordgo.ChannelTypeGuildDirectory,
+	"forum":               discordgo.ChannelTypeGuildForum,
+	"media":               discordgo.ChannelTypeGuildMedia,
 }
@@ -0,0 +1,201 @@
+package discord
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestBuildSlashCommand_RequiredOptionsFirst(t *testing.T) {
+	bot := &Bot{}
+
+	cmd := config.CommandSpec{
+		Name: "report",
+		Type: "slash",
+		Fields: []config.FieldSpec{
+			{Name: "notes", Type: "text", Required: false},
+			{Name: "title", Type: "text", Required: true},
+			{Name: "file", Type: "attachment", Required: false},
+			{Name: "priority", Type: "select", Options: []string{"Low", "High"}, Required: true},
+		},
+	}
+
+	command, err := bot.buildSlashCommand(cmd)
+	if err != nil {
+		t.Fatalf("Failed to build command: %v", err)
+	}
+
+	expected := []struct {
+		name     string
+		required bool
+	}{
+		{"title", true},
+		{"priority", true},
+		{"notes", false},
+		{"file", false},
+	}
+
+	if len(command.Options) != len(expected) {
+		t.Fatalf("Expected %d options, got %d", len(expected), len(command.Options))
+	}
+
+	for i, want := range expected {
+		option := command.Options[i]
+		if option.Name != want.name || option.Required != want.required {
+			t.Errorf("Option %d: expected %s (required=%v), got %s (required=%v)", i, want.name, want.required, option.Name, option.Required)
+		}
+	}
+}
+
+func TestCreateCommandOption_TypedOptions(t *testing.T) {
+	bot := &Bot{}
+
+	minValue, maxValue := 1.0, 10.0
+	maxLength := 50
+
+	tests := []struct {
+		field    config.FieldSpec
+		expected discordgo.ApplicationCommandOptionType
+	}{
+		{config.FieldSpec{Name: "count", Type: "integer", MinValue: &minValue, MaxValue: &maxValue}, discordgo.ApplicationCommandOptionInteger},
+		{config.FieldSpec{Name: "ratio", Type: "number"}, discordgo.ApplicationCommandOptionNumber},
+		{config.FieldSpec{Name: "urgent", Type: "boolean"}, discordgo.ApplicationCommandOptionBoolean},
+		{config.FieldSpec{Name: "assignee", Type: "user"}, discordgo.ApplicationCommandOptionUser},
+		{config.FieldSpec{Name: "where", Type: "channel", ChannelTypes: []string{"text", "forum"}}, discordgo.ApplicationCommandOptionChannel},
+		{config.FieldSpec{Name: "team", Type: "role"}, discordgo.ApplicationCommandOptionRole},
+		{config.FieldSpec{Name: "target", Type: "mentionable"}, discordgo.ApplicationCommandOptionMentionable},
+		{config.FieldSpec{Name: "title", Type: "text", MaxLength: &maxLength}, discordgo.ApplicationCommandOptionString},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field.Name, func(t *testing.T) {
+			option, err := bot.createCommandOption(tt.field)
+			if err != nil {
+				t.Fatalf("Failed to create option: %v", err)
+			}
+			if option.Type != tt.expected {
+				t.Errorf("Expected option type %v, got %v", tt.expected, option.Type)
+			}
+		})
+	}
+
+	option, _ := bot.createCommandOption(tests[0].field)
+	if option.MinValue == nil || *option.MinValue != 1 || option.MaxValue != 10 {
+		t.Errorf("Expected min_value 1 and max_value 10, got %v and %v", option.MinValue, option.MaxValue)
+	}
+
+	option, _ = bot.createCommandOption(tests[4].field)
+	if len(option.ChannelTypes) != 2 || option.ChannelTypes[0] != discordgo.ChannelTypeGuildText || option.ChannelTypes[1] != discordgo.ChannelTypeGuildForum {
+		t.Errorf("Expected text and forum channel types, got %v", option.ChannelTypes)
+	}
+
+	option, _ = bot.createCommandOption(tests[7].field)
+	if option.MaxLength != 50 {
+		t.Errorf("Expected max_length 50, got %d", option.MaxLength)
+	}
+}
+
+func TestCreateCommandOption_NegativeRange(t *testing.T) {
+	bot := &Bot{}
+	minValue, maxValue := -10.0, -1.0
+
+	option, err := bot.createCommandOption(config.FieldSpec{Name: "delta", Type: "integer", MinValue: &minValue, MaxValue: &maxValue})
+	if err != nil {
+		t.Fatalf("Failed to create option: %v", err)
+	}
+
+	body, err := json.Marshal(option)
+	if err != nil {
+		t.Fatalf("Failed to marshal option: %v", err)
+	}
+	if !strings.Contains(string(body), `"min_value":-10`) || !strings.Contains(string(body), `"max_value":-1`) {
+		t.Errorf("Expected both bounds in the option sent to Discord, got %s", body)
+	}
+}
+
+func TestCommandScopes(t *testing.T) {
+	// The report command moved from guild A to guild B before a restart, so
+	// guild A is only known because the bot is in it
+	cfg := &config.Config{
+		Commands: []config.CommandSpec{
+			{Name: "report", Type: "slash", Guilds: []string{"200000000000000002"}},
+			{Name: "feedback", Type: "modal"},
+		},
+	}
+
+	scopes := commandScopes(cfg, map[string]bool{}, []string{"100000000000000001", "200000000000000002"})
+
+	if len(scopes) != 3 {
+		t.Fatalf("Expected global and 2 guild scopes, got %v", scopes)
+	}
+	if commands, ok := scopes["100000000000000001"]; !ok || len(commands) != 0 {
+		t.Errorf("Expected guild A to be synced with no commands, got %v", commands)
+	}
+	if commands := scopes["200000000000000002"]; len(commands) != 1 || commands[0].Name != "report" {
+		t.Errorf("Expected report in guild B, got %v", commands)
+	}
+	if commands := scopes[""]; len(commands) != 1 || commands[0].Name != "feedback" {
+		t.Errorf("Expected feedback to be global, got %v", commands)
+	}
+}
+
+func TestClaimGuilds(t *testing.T) {
+	bot := &Bot{sweptGuilds: map[string]bool{"100000000000000001": true}}
+
+	claimed := bot.claimGuilds([]string{"100000000000000001", "200000000000000002"})
+	if len(claimed) != 1 || claimed[0] != "200000000000000002" {
+		t.Errorf("Expected only the unchecked guild, got %v", claimed)
+	}
+	if claimed := bot.claimGuilds([]string{"200000000000000002"}); len(claimed) != 0 {
+		t.Errorf("Expected a guild to be checked only once, got %v", claimed)
+	}
+}
+
+func TestSessionGuilds(t *testing.T) {
+	if guilds := (&Bot{}).sessionGuilds(); len(guilds) != 0 {
+		t.Errorf("Expected no guilds without a session, got %v", guilds)
+	}
+
+	state := discordgo.NewState()
+	state.Guilds = []*discordgo.Guild{{ID: "100000000000000001"}, {ID: "200000000000000002"}}
+	bot := &Bot{Session: &discordgo.Session{State: state}}
+
+	guilds := bot.sessionGuilds()
+	if len(guilds) != 2 || guilds[0] != "100000000000000001" || guilds[1] != "200000000000000002" {
+		t.Errorf("Expected both joined guilds, got %v", guilds)
+	}
+}
+
+func TestBuildSlashCommand_DefaultMemberPermissions(t *testing.T) {
+	bot := &Bot{}
+	manageMessages, adminsOnly := int64(discordgo.PermissionManageMessages), int64(0)
+
+	tests := []struct {
+		name   string
+		access *config.AccessSpec
+		want   *int64
+	}{
+		{"no access block", nil, nil},
+		{"permissions", &config.AccessSpec{Permissions: []string{"manage_messages"}}, &manageMessages},
+		{"role allow list", &config.AccessSpec{AllowRoles: []string{"345678901234567890"}}, &adminsOnly},
+		{"channel allow list with permissions", &config.AccessSpec{AllowChannels: []string{"678901234567890123"}, Permissions: []string{"manage_messages"}}, &adminsOnly},
+		{"deny list only", &config.AccessSpec{DenyUsers: []string{"567890123456789012"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, err := bot.buildSlashCommand(config.CommandSpec{Name: "deploy", Type: "slash", Access: tt.access})
+			if err != nil {
+				t.Fatalf("Failed to build command: %v", err)
+			}
+			got := command.DefaultMemberPermissions
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("Expected default member permissions %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package discord
+
+import (
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord's limits for a single embed.
+const (
+	maxEmbedTitle       = 256
+	maxEmbedDescription = 4096
+	maxEmbedFields      = 25
+	maxEmbedFieldName   = 256
+	maxEmbedFieldValue  = 1024
+	maxEmbedFooter      = 2048
+	maxEmbedTotal       = 6000
+)
+
+// minFittedFieldValue is the length below which fitEmbed does not shorten
+// field values to keep an embed within maxEmbedTotal.
+const minFittedFieldValue = 64
+
+// Embed colours following the outcome of a submission.
+const (
+	embedColorSuccess = 0x57F287
+	embedColorWarning = 0xFEE75C
+	embedColorFailure = 0xED4245
+)
+
+// responseEmbed is the reply to a submission in the embed style: the
+// command's success or failure message, or the webhook status, with one
+// embed field per submitted value, a footer naming the submitter and the
+// time of the submission. Values are cut to Discord's embed limits.
+func responseEmbed(cmd *config.CommandSpec, i *discordgo.InteractionCreate, display map[string]string, results []deliveryResult, now time.Time) *discordgo.MessageEmbed {
+	spec := cmd.Response.Embed
+	data := responseData(cmd, i, display)
+	data.Delivery = deliveryData(results)
+
+	embed := &discordgo.MessageEmbed{
+		Title:     strings.Title(cmd.Name),
+		Color:     embedColor(data.Delivery.Status),
+		Timestamp: now.UTC().Format(time.RFC3339),
+	}
+
+	if color, ok := spec.ColorValue(); ok {
+		embed.Color = color
+	}
+	if spec != nil && spec.Title != "" {
+		if title, err := cmd.Response.Render("embed.title", data); err != nil {
+			log.Printf("Error rendering embed title for %s: %v", cmd.Name, err)
+		} else {
+			embed.Title = title
+		}
+	}
+
+	if description, ok := customResponse(cmd, i, display, results); ok {
+		embed.Description = description
+	} else if status := strings.TrimSpace(webhookStatuses(results, cmd.Response.HidesEndpoint())); status != "" {
+		embed.Description = status
+	} else {
+		embed.Description = "✨ **Thank you for your submission!**"
+	}
+
+	if !cmd.Response.HidesFields() {
+		for _, field := range cmd.Fields {
+			value := strings.TrimSpace(display[field.Name])
+			if value == "" {
+				if !field.Required {
+					continue
+				}
+				value = "*Not provided*"
+			}
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name:   strings.Title(field.Name),
+				Value:  value,
+				Inline: field.Type != "textarea",
+			})
+		}
+	}
+
+	user := interactionUser(i)
+	if user != nil {
+		embed.Footer = &discordgo.MessageEmbedFooter{
+			Text:    "Submitted by " + data.User.DisplayName,
+			IconURL: user.AvatarURL(""),
+		}
+	}
+	if spec != nil && spec.Thumbnail != "" {
+		thumbnail := spec.Thumbnail
+		if thumbnail == config.AvatarThumbnail {
+			thumbnail = ""
+			if user != nil {
+				thumbnail = user.AvatarURL("")
+			}
+		}
+		if thumbnail != "" {
+			embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: thumbnail}
+		}
+	}
+
+	fitEmbed(embed)
+	return embed
+}
+
+// embedColor returns the colour of an embed for a delivery status.
+func embedColor(status string) int {
+	switch status {
+	case "failed":
+		return embedColorFailure
+	case "partial", "queued":
+		return embedColorWarning
+	default:
+		return embedColorSuccess
+	}
+}
+
+// fitEmbed cuts an embed down to Discord's limits. Every part is cut to its
+// own limit first; if the embed is still too long in total, the longest
+// field values are shortened, and then the description.
+func fitEmbed(embed *discordgo.MessageEmbed) {
+	embed.Title = truncate(embed.Title, maxEmbedTitle)
+	embed.Description = truncate(embed.Description, maxEmbedDescription)
+	if len(embed.Fields) > maxEmbedFields {
+		embed.Fields = embed.Fields[:maxEmbedFields]
+	}
+	for _, field := range embed.Fields {
+		field.Name = truncate(field.Name, maxEmbedFieldName)
+		field.Value = truncate(field.Value, maxEmbedFieldValue)
+	}
+	if embed.Footer != nil {
+		embed.Footer.Text = truncate(embed.Footer.Text, maxEmbedFooter)
+	}
+
+	for excess := embedLength(embed) - maxEmbedTotal; excess > 0; excess = embedLength(embed) - maxEmbedTotal {
+		longest := longestField(embed.Fields)
+		if longest == nil || utf8.RuneCountInString(longest.Value) <= minFittedFieldValue {
+			break
+		}
+		length := utf8.RuneCountInString(longest.Value)
+		longest.Value = truncate(longest.Value, max(length-excess, minFittedFieldValue))
+	}
+
+	if excess := embedLength(embed) - maxEmbedTotal; excess > 0 {
+		embed.Description = truncate(embed.Description, max(utf8.RuneCountInString(embed.Description)-excess, 1))
+	}
+}
+
+// embedLength counts the characters Discord limits to maxEmbedTotal.
+func embedLength(embed *discordgo.MessageEmbed) int {
+	length := utf8.RuneCountInString(embed.Title) + utf8.RuneCountInString(embed.Description)
+	for _, field := range embed.Fields {
+		length += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+	}
+	if embed.Footer != nil {
+		length += utf8.RuneCountInString(embed.Footer.Text)
+	}
+	return length
+}
+
+func longestField(fields []*discordgo.MessageEmbedField) *discordgo.MessageEmbedField {
+	var longest *discordgo.MessageEmbedField
+	for _, field := range fields {
+		if longest == nil || utf8.RuneCountInString(field.Value) > utf8.RuneCountInString(longest.Value) {
+			longest = field
+		}
+	}
+	return longest
+}
+
+// editEmbed replaces the "thinking" placeholder of a deferred response with
+// an embed. Mentions in the embed never ping anyone.
+func (b *Bot) editEmbed(s *discordgo.Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed) {
+	content := ""
+	embeds := []*discordgo.MessageEmbed{embed}
+	edit := &discordgo.WebhookEdit{
+		Content:         &content,
+		Embeds:          &embeds,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, edit); err != nil {
+		log.Printf("Error editing interaction response: %v", err)
+	}
+}
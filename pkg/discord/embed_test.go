@@ -0,0 +1,128 @@
+package discord
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestResponseEmbed(t *testing.T) {
+	cmd := &config.CommandSpec{
+		Name:    "ticket",
+		Webhook: config.WebhookSpec{URL: "https://example.com/tickets"},
+		Response: &config.ResponseSpec{
+			Style: "embed",
+			Embed: &config.EmbedSpec{
+				Color:     "#5865F2",
+				Title:     "Ticket from {{ .User.DisplayName }}",
+				Thumbnail: "avatar",
+			},
+		},
+		Fields: []config.FieldSpec{
+			{Name: "title", Type: "text", Required: true},
+			{Name: "details", Type: "textarea"},
+			{Name: "notes", Type: "text"},
+			{Name: "email", Type: "text", Required: true},
+		},
+	}
+	display := map[string]string{"title": "Printer on fire", "details": "It smells of smoke"}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	embed := responseEmbed(cmd, testInteraction(), display, []deliveryResult{{target: cmd.Targets()[0], attempts: 1}}, now)
+
+	if embed.Title != "Ticket from Ally" {
+		t.Errorf("Expected rendered title, got '%s'", embed.Title)
+	}
+	if embed.Color != 0x5865F2 {
+		t.Errorf("Expected configured colour, got %#x", embed.Color)
+	}
+	if !strings.Contains(embed.Description, "Data sent successfully") {
+		t.Errorf("Expected the webhook status as description, got '%s'", embed.Description)
+	}
+	if embed.Timestamp != "2024-05-01T12:00:00Z" {
+		t.Errorf("Expected submission timestamp, got '%s'", embed.Timestamp)
+	}
+	if embed.Footer == nil || embed.Footer.Text != "Submitted by Ally" {
+		t.Errorf("Expected footer naming the submitter, got %+v", embed.Footer)
+	}
+	if embed.Thumbnail == nil || !strings.HasPrefix(embed.Thumbnail.URL, "https://cdn.discordapp.com/") {
+		t.Errorf("Expected the submitter's avatar as thumbnail, got %+v", embed.Thumbnail)
+	}
+
+	// Empty optional fields are left out, empty required ones are marked
+	want := []struct {
+		name, value string
+		inline      bool
+	}{
+		{"Title", "Printer on fire", true},
+		{"Details", "It smells of smoke", false},
+		{"Email", "*Not provided*", true},
+	}
+	if len(embed.Fields) != len(want) {
+		t.Fatalf("Expected %d fields, got %d", len(want), len(embed.Fields))
+	}
+	for j, field := range embed.Fields {
+		if field.Name != want[j].name || field.Value != want[j].value || field.Inline != want[j].inline {
+			t.Errorf("Field %d: expected %+v, got %+v", j, want[j], *field)
+		}
+	}
+}
+
+func TestResponseEmbed_Outcome(t *testing.T) {
+	cmd := &config.CommandSpec{
+		Name:     "ticket",
+		Webhook:  config.WebhookSpec{URL: "https://example.com/tickets"},
+		Response: &config.ResponseSpec{Style: "embed", Failure: "Sorry, {{ .Delivery.Error }}", HideFields: true},
+		Fields:   []config.FieldSpec{{Name: "title", Type: "text"}},
+	}
+	results := []deliveryResult{{target: cmd.Targets()[0], attempts: 1, err: errors.New("webhook returned status 500")}}
+
+	embed := responseEmbed(cmd, testInteraction(), map[string]string{"title": "Printer on fire"}, results, time.Now())
+
+	if embed.Color != embedColorFailure {
+		t.Errorf("Expected failure colour, got %#x", embed.Color)
+	}
+	if embed.Description != "Sorry, webhook returned status 500" {
+		t.Errorf("Expected the failure message, got '%s'", embed.Description)
+	}
+	if len(embed.Fields) != 0 {
+		t.Errorf("Expected no fields, got %d", len(embed.Fields))
+	}
+	if embed.Title != "Ticket" {
+		t.Errorf("Expected the command name as title, got '%s'", embed.Title)
+	}
+}
+
+func TestFitEmbed(t *testing.T) {
+	embed := &discordgo.MessageEmbed{
+		Title:       strings.Repeat("t", 300),
+		Description: strings.Repeat("d", 5000),
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Submitted by Ally"},
+	}
+	for j := 0; j < 30; j++ {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Notes", Value: strings.Repeat("é", 2000)})
+	}
+
+	fitEmbed(embed)
+
+	if length := utf8.RuneCountInString(embed.Title); length != maxEmbedTitle {
+		t.Errorf("Expected title cut to %d characters, got %d", maxEmbedTitle, length)
+	}
+	if len(embed.Fields) != maxEmbedFields {
+		t.Errorf("Expected %d fields, got %d", maxEmbedFields, len(embed.Fields))
+	}
+	for _, field := range embed.Fields {
+		if utf8.RuneCountInString(field.Value) > maxEmbedFieldValue {
+			t.Fatalf("Expected field values within %d characters, got %d", maxEmbedFieldValue, utf8.RuneCountInString(field.Value))
+		}
+	}
+	if length := embedLength(embed); length > maxEmbedTotal {
+		t.Errorf("Expected at most %d characters in total, got %d", maxEmbedTotal, length)
+	}
+}
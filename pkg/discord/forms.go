@@ -1,11 +1,15 @@
 package discord
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"yambot/pkg/config"
 
@@ -18,56 +22,120 @@ func (b *Bot) handleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCr
 		return
 	}
 
-	if !strings.HasPrefix(i.ModalSubmitData().CustomID, "modal_") {
+	// discordgo cannot decode the label components wrapping select menus, so
+	// such submissions arrive without data and handleRawModalSubmit takes over.
+	if i.Data == nil {
 		return
 	}
 
-	commandName := strings.TrimPrefix(i.ModalSubmitData().CustomID, "modal_")
-	log.Printf("Received modal submission for command: %s", commandName)
+	modalData := i.ModalSubmitData()
+	b.processModalSubmit(s, i, modalData.CustomID, b.extractFormData(&modalData))
+}
 
-	var commandSpec *config.CommandSpec
-	for _, cmd := range b.Config.GetCommands() {
-		if cmd.Name == commandName {
-			commandSpec = &cmd
-			break
-		}
+// processModalSubmit validates a submitted form and forwards it to the
+// command's webhook. Text input values are strings, select menu values are
+// string slices.
+func (b *Bot) processModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate, customID string, formData map[string]interface{}) {
+	if !strings.HasPrefix(customID, "modal_") {
+		return
 	}
 
+	commandName, sessionID, page := parseModalCustomID(strings.TrimPrefix(customID, "modal_"))
+	log.Printf("Received modal submission for command: %s", commandName)
+
+	commandSpec := b.findCommandSpec(commandName, i.GuildID)
 	if commandSpec == nil {
 		log.Printf("Unknown command: %s", commandName)
 		return
 	}
 
-	modalData := i.ModalSubmitData()
-	formData := b.extractFormData(&modalData)
+	if allowed, reason := checkAccess(commandSpec.Access, i); !allowed {
+		log.Printf("Access denied to modal submission for %s: %s", commandName, reason)
+		b.respondWithError(s, i, accessDeniedMessage(commandSpec.Access))
+		return
+	}
 
-	if err := b.validateFormData(commandSpec, formData); err != nil {
-		response := fmt.Sprintf("❌ **Validation Error**\n\n%s\n\nPlease check your input and try again.", err.Error())
-		b.respondWithError(s, i, response)
+	// Each page of a multi-page form is validated as it is submitted.
+	pages := modalPages(commandSpec.Fields)
+	pageSpec := *commandSpec
+	if sessionID != "" {
+		if page < 0 || page >= len(pages) {
+			log.Printf("Modal submission for %s has unknown page %d", commandName, page+1)
+			b.respondWithError(s, i, wizardExpiredMessage)
+			return
+		}
+		pageSpec.Fields = pages[page]
+	}
+
+	if err := b.validateLocalValues(&pageSpec, formData); err != nil {
+		b.respondWithError(s, i, validationResponse(commandSpec, i, displayFormData(formData), err))
 		return
 	}
 
-	var webhookError error
-	if commandSpec.Webhook != "" {
-		webhookError = b.WebhookService.SendWebhook(commandSpec.Webhook, formData)
+	// remote_select lookups and the webhook may take longer than Discord's
+	// 3 second window, so the response is deferred and edited afterwards.
+	// Pages before the last only get a private "Continue" reply, and
+	// ephemeral commands reply privately on the last page too.
+	lastPage := sessionID == "" || page == len(pages)-1
+	if err := b.deferResponse(s, i, !lastPage || commandSpec.IsEphemeral()); err != nil {
+		log.Printf("Error deferring modal submission response: %v", err)
+		return
 	}
 
-	response := b.createFormResponse(commandSpec, formData, webhookError)
+	if err := b.validateRemoteValues(&pageSpec, formData); err != nil {
+		b.failDeferred(s, i, validationResponse(commandSpec, i, displayFormData(formData), err))
+		return
+	}
 
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: response,
-		},
-	})
+	if sessionID != "" {
+		userID := ""
+		if user := interactionUser(i); user != nil {
+			userID = user.ID
+		}
 
-	if err != nil {
-		log.Printf("Error responding to modal submission: %v", err)
+		merged, err := b.saveWizardPage(sessionID, userID, page, formData)
+		if err != nil {
+			log.Printf("Cannot save page %d of form session %s: %v", page+1, sessionID, err)
+			b.failDeferred(s, i, wizardExpiredMessage)
+			return
+		}
+
+		if !lastPage {
+			b.editResponse(s, i, continueMessage(page+1, len(pages)), continueComponents(sessionID))
+			return
+		}
+
+		b.endWizard(sessionID)
+		formData = merged
+	}
+
+	var results []deliveryResult
+	if commandSpec.HasWebhook() {
+		results = b.submitWebhook(commandSpec, i, formData, nil)
+	}
+
+	display := displayFormData(formData)
+	if !submissionFailed(results) {
+		b.postNotice(s, commandSpec, i, display, results)
+	}
+	if reply := replyFrom(results); reply != nil {
+		b.sendReply(s, i, reply, commandSpec.IsEphemeral())
+		return
+	}
+	if commandSpec.Response.UsesEmbed() {
+		b.editEmbed(s, i, responseEmbed(commandSpec, i, display, results, time.Now()))
+		return
+	}
+
+	response, ok := customResponse(commandSpec, i, display, results)
+	if !ok {
+		response = b.createFormResponse(commandSpec, display, results)
 	}
+	b.editResponse(s, i, response, nil)
 }
 
-func (b *Bot) extractFormData(data *discordgo.ModalSubmitInteractionData) map[string]string {
-	formData := make(map[string]string)
+func (b *Bot) extractFormData(data *discordgo.ModalSubmitInteractionData) map[string]interface{} {
+	formData := make(map[string]interface{})
 
 	for _, component := range data.Components {
 		if actionRow, ok := component.(*discordgo.ActionsRow); ok {
@@ -82,10 +150,24 @@ func (b *Bot) extractFormData(data *discordgo.ModalSubmitInteractionData) map[st
 	return formData
 }
 
-func (b *Bot) createFormResponse(cmd *config.CommandSpec, formData map[string]string, webhookError error) string {
-	response := fmt.Sprintf("✅ **Form Successfully Submitted**\n\n📋 **Command**: %s\n\n", strings.Title(cmd.Name))
+func (b *Bot) createFormResponse(cmd *config.CommandSpec, formData map[string]string, results []deliveryResult) string {
+	response := fmt.Sprintf("✅ **Form Successfully Submitted**\n\n📋 **Command**: %s", strings.Title(cmd.Name))
 
-	response += "**📝 Submitted Data:**\n"
+	if !cmd.Response.HidesFields() {
+		response += "\n\n" + formDataSummary(cmd, formData)
+	}
+
+	response += webhookStatuses(results, cmd.Response.HidesEndpoint())
+
+	response += "\n\n✨ **Thank you for your submission!**"
+
+	return response
+}
+
+// formDataSummary lists the submitted values of a form and how many fields
+// were filled.
+func formDataSummary(cmd *config.CommandSpec, formData map[string]string) string {
+	response := "**📝 Submitted Data:**\n"
 	requiredFields := 0
 	filledFields := 0
 
@@ -130,114 +212,229 @@ func (b *Bot) createFormResponse(cmd *config.CommandSpec, formData map[string]st
 		response += fmt.Sprintf(" (%d required)", requiredFields)
 	}
 
-	if cmd.Webhook != "" {
-		if webhookError != nil {
-			response += fmt.Sprintf("\n\n❌ **Webhook Status**: Failed to send data\n🌐 **Endpoint**: %s\n⚠️ **Error**: %s", cmd.Webhook, webhookError.Error())
-		} else {
-			response += fmt.Sprintf("\n\n✅ **Webhook Status**: Data sent successfully\n🌐 **Endpoint**: %s", cmd.Webhook)
+	return response
+}
+
+// webhookStatuses reports the outcome of a submission: the status of its
+// webhook, or for commands with several destinations an overall status
+// followed by one line per destination. With hideEndpoint, webhook URLs are
+// left out and unnamed destinations are numbered instead.
+func webhookStatuses(results []deliveryResult, hideEndpoint bool) string {
+	switch len(results) {
+	case 0:
+		return ""
+	case 1:
+		endpoint := results[0].target.Webhook.URL
+		if hideEndpoint {
+			endpoint = ""
 		}
+		return webhookStatus(endpoint, results[0].attempts, results[0].err)
 	}
 
-	response += "\n\n✨ **Thank you for your submission!**"
+	failed, queued := 0, 0
+	lines := ""
+	for j, result := range results {
+		label := result.target.Label()
+		if hideEndpoint && result.target.Name == "" {
+			label = fmt.Sprintf("Destination %d", j+1)
+		}
+		name := fmt.Sprintf("**%s**", label)
+		if !result.target.IsRequired() {
+			name += " (optional)"
+		}
 
-	return response
+		tries := ""
+		if result.attempts > 1 {
+			tries = fmt.Sprintf(" after %d attempts", result.attempts)
+		}
+
+		var queuedErr *queuedError
+		switch {
+		case errors.As(result.err, &queuedErr):
+			queued++
+			lines += fmt.Sprintf("\n⏳ %s: Not delivered yet%s, queued for another try (%s)", name, tries, queuedErr.Unwrap().Error())
+		case result.err != nil:
+			failed++
+			lines += fmt.Sprintf("\n❌ %s: Failed to send data%s (%s)", name, tries, result.err.Error())
+		default:
+			lines += fmt.Sprintf("\n✅ %s: Data sent successfully%s", name, tries)
+		}
+	}
+
+	var summary string
+	switch {
+	case submissionFailed(results):
+		summary = "❌ **Webhook Status**: Submission failed"
+	case failed > 0:
+		summary = "⚠️ **Webhook Status**: Submitted, but some optional destinations failed"
+	case queued > 0:
+		summary = "⏳ **Webhook Status**: Submitted, some destinations are queued for another try"
+	default:
+		summary = fmt.Sprintf("✅ **Webhook Status**: Data sent to all %d destinations", len(results))
+	}
+
+	return "\n\n" + summary + lines
+}
+
+// webhookStatus reports the outcome of a webhook delivery, mentioning the
+// number of attempts when it took more than one. The endpoint line is left
+// out when endpoint is empty.
+func webhookStatus(endpoint string, attempts int, webhookError error) string {
+	tries := ""
+	if attempts > 1 {
+		tries = fmt.Sprintf(" after %d attempts", attempts)
+	}
+
+	endpointLine := ""
+	if endpoint != "" {
+		endpointLine = fmt.Sprintf("\n🌐 **Endpoint**: %s", endpoint)
+	}
+
+	var queued *queuedError
+	if errors.As(webhookError, &queued) {
+		return fmt.Sprintf("\n\n⏳ **Webhook Status**: Not delivered yet%s, queued for another try%s\n⚠️ **Error**: %s", tries, endpointLine, queued.Unwrap().Error())
+	}
+	if webhookError != nil {
+		return fmt.Sprintf("\n\n❌ **Webhook Status**: Failed to send data%s%s\n⚠️ **Error**: %s", tries, endpointLine, webhookError.Error())
+	}
+	return fmt.Sprintf("\n\n✅ **Webhook Status**: Data sent successfully%s%s", tries, endpointLine)
 }
 
-func (b *Bot) createModalComponents(cmd *config.CommandSpec) []discordgo.MessageComponent {
+func (b *Bot) createModalComponents(cmd *config.CommandSpec) ([]discordgo.MessageComponent, error) {
+	remoteOptions, err := b.fetchModalOptions(cmd.Fields)
+	if err != nil {
+		return nil, err
+	}
+
 	var rows []discordgo.MessageComponent
 
 	for _, field := range cmd.Fields {
-		if field.Type == "text" || field.Type == "textarea" {
-			style := discordgo.TextInputShort
-			maxLength := 1000
-
-			if field.Type == "textarea" {
-				style = discordgo.TextInputParagraph
-				maxLength = 4000
-			} else if strings.Contains(strings.ToLower(field.Name), "description") ||
-				strings.Contains(strings.ToLower(field.Name), "details") ||
-				strings.Contains(strings.ToLower(field.Name), "comment") {
-				style = discordgo.TextInputParagraph
-				maxLength = 4000
-			}
-
-			row := discordgo.ActionsRow{
-				Components: []discordgo.MessageComponent{
-					discordgo.TextInput{
-						CustomID:    field.Name,
-						Label:       strings.Title(field.Name),
-						Style:       style,
-						Placeholder: fmt.Sprintf("Enter %s", field.Name),
-						Required:    field.Required,
-						MaxLength:   maxLength,
-					},
-				},
+		switch field.Type {
+		case "text", "textarea":
+			rows = append(rows, createTextInputRow(field))
+		case "select", "remote_select":
+			label, err := createSelectLabel(field, remoteOptions[field.Name])
+			if err != nil {
+				return nil, fmt.Errorf("failed to create select menu for field %s: %w", field.Name, err)
 			}
-			rows = append(rows, row)
-		} else {
-			log.Printf("Warning: Field type '%s' for field '%s' is not supported in Discord modals. Only 'text' and 'textarea' fields are supported in modals.", field.Type, field.Name)
+			rows = append(rows, label)
+		default:
+			log.Printf("Warning: Field type '%s' for field '%s' is not supported in Discord modals. Only 'text', 'textarea', 'select' and 'remote_select' fields are supported in modals.", field.Type, field.Name)
 		}
 	}
 
-	return rows
+	return rows, nil
 }
 
-func (b *Bot) validateFormData(cmd *config.CommandSpec, formData map[string]string) error {
+func createTextInputRow(field config.FieldSpec) discordgo.ActionsRow {
+	style := discordgo.TextInputShort
+	maxLength := 1000
+
+	if field.Type == "textarea" {
+		style = discordgo.TextInputParagraph
+		maxLength = 4000
+	} else if strings.Contains(strings.ToLower(field.Name), "description") ||
+		strings.Contains(strings.ToLower(field.Name), "details") ||
+		strings.Contains(strings.ToLower(field.Name), "comment") {
+		style = discordgo.TextInputParagraph
+		maxLength = 4000
+	}
+
+	minLength := 0
+	if field.MinLength != nil {
+		minLength = *field.MinLength
+	}
+	if field.MaxLength != nil && *field.MaxLength < maxLength {
+		maxLength = *field.MaxLength
+	}
+
+	return discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{
+			discordgo.TextInput{
+				CustomID:    field.Name,
+				Label:       strings.Title(field.Name),
+				Style:       style,
+				Placeholder: fmt.Sprintf("Enter %s", field.Name),
+				Required:    field.Required,
+				MinLength:   minLength,
+				MaxLength:   maxLength,
+			},
+		},
+	}
+}
+
+// validateLocalValues runs the checks that need no network access, so they
+// can be answered within Discord's response window.
+func (b *Bot) validateLocalValues(cmd *config.CommandSpec, formData map[string]interface{}) error {
 	var errors []string
 
 	for _, field := range cmd.Fields {
-		value, exists := formData[field.Name]
-		isEmpty := !exists || strings.TrimSpace(value) == ""
+		values := formValueStrings(formData[field.Name])
+		isEmpty := len(values) == 0
 
 		switch field.Type {
 		case "text", "textarea":
 			if field.Required && isEmpty {
 				errors = append(errors, fmt.Sprintf("• **%s** is required", strings.Title(field.Name)))
-			} else if exists && !isEmpty {
-				if err := b.validateTextFormat(field, value); err != nil {
-					errors = append(errors, fmt.Sprintf("• **%s** %s", strings.Title(field.Name), err.Error()))
+			} else if !isEmpty {
+				if problem := b.validateText(field, values[0]); problem != "" {
+					errors = append(errors, problem)
 				}
 			}
 		case "select":
 			if field.Required && isEmpty {
 				errors = append(errors, fmt.Sprintf("• **%s** is required", strings.Title(field.Name)))
-			} else if exists && !isEmpty && len(field.Options) > 0 {
-				valid := false
-				for _, option := range field.Options {
-					if value == option {
-						valid = true
-						break
+			} else if !isEmpty && len(field.Options) > 0 {
+				for _, value := range values {
+					if !slices.Contains(field.Options, value) {
+						availableOptions := strings.Join(field.Options, ", ")
+						errors = append(errors, fmt.Sprintf("• **%s** has invalid value '%s'. Available options: %s", strings.Title(field.Name), value, availableOptions))
 					}
 				}
-				if !valid {
-					availableOptions := strings.Join(field.Options, ", ")
-					errors = append(errors, fmt.Sprintf("• **%s** has invalid value '%s'. Available options: %s", strings.Title(field.Name), value, availableOptions))
-				}
 			}
-		case "remote_select":
+		case "remote_select", "attachment":
 			if field.Required && isEmpty {
 				errors = append(errors, fmt.Sprintf("• **%s** is required", strings.Title(field.Name)))
-			} else if exists && !isEmpty && field.Webhook != "" {
-				remoteOptions, err := b.fetchRemoteOptions(field.Webhook)
-				if err != nil {
-					log.Printf("Failed to fetch remote options for validation: %v", err)
-					errors = append(errors, fmt.Sprintf("• **%s** could not validate options (remote service unavailable)", strings.Title(field.Name)))
-				} else {
-					valid := false
-					for _, option := range remoteOptions {
-						if value == option.Value {
-							valid = true
-							break
-						}
-					}
-					if !valid {
-						errors = append(errors, fmt.Sprintf("• **%s** has invalid value '%s'", strings.Title(field.Name), value))
-					}
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("Please fix the following issues:\n%s", strings.Join(errors, "\n"))
+	}
+
+	return nil
+}
+
+// validateRemoteValues checks remote_select values against the options their
+// webhook currently offers.
+func (b *Bot) validateRemoteValues(cmd *config.CommandSpec, formData map[string]interface{}) error {
+	var errors []string
+
+	for _, field := range cmd.Fields {
+		values := formValueStrings(formData[field.Name])
+		if field.Type != "remote_select" || !field.Webhook.IsSet() || len(values) == 0 {
+			continue
+		}
+
+		// Endpoints filter by what users type, usually the label, so values
+		// are checked against the unfiltered list
+		remoteOptions, err := b.fetchRemoteOptions(context.Background(), field.Webhook, "")
+		if err != nil {
+			log.Printf("Failed to fetch remote options for validation: %v", err)
+			errors = append(errors, fmt.Sprintf("• **%s** could not validate options (remote service unavailable)", strings.Title(field.Name)))
+			continue
+		}
+		for _, value := range values {
+			valid := false
+			for _, option := range remoteOptions {
+				if value == option.Value {
+					valid = true
+					break
 				}
 			}
-		case "attachment":
-			if field.Required && isEmpty {
-				errors = append(errors, fmt.Sprintf("• **%s** is required", strings.Title(field.Name)))
+			if !valid {
+				errors = append(errors, fmt.Sprintf("• **%s** has invalid value '%s'", strings.Title(field.Name), value))
 			}
 		}
 	}
@@ -249,6 +446,55 @@ func (b *Bot) validateFormData(cmd *config.CommandSpec, formData map[string]stri
 	return nil
 }
 
+// validateSlashOptions checks the text options of a slash command against
+// their fields' validate rules. Discord itself enforces the other option
+// types, and fields without rules are accepted as typed.
+func (b *Bot) validateSlashOptions(cmd *config.CommandSpec, options []*discordgo.ApplicationCommandInteractionDataOption) error {
+	var errors []string
+
+	for _, option := range options {
+		field := findFieldSpec(cmd, option.Name)
+		if field == nil || field.Validate == nil || option.Type != discordgo.ApplicationCommandOptionString {
+			continue
+		}
+		value := option.StringValue()
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		if problem := b.validateText(*field, value); problem != "" {
+			errors = append(errors, problem)
+		}
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("Please fix the following issues:\n%s", strings.Join(errors, "\n"))
+	}
+
+	return nil
+}
+
+// validateText checks a text value against the field's validate rules, or
+// against checks guessed from the field name when it has none. It returns the
+// problem as a list item, or "" when the value is fine.
+func (b *Bot) validateText(field config.FieldSpec, value string) string {
+	if field.Validate != nil {
+		if err := field.Validate.Check(value); err != nil {
+			if field.Validate.Message != "" {
+				return fmt.Sprintf("• **%s**: %s", strings.Title(field.Name), err.Error())
+			}
+			return fmt.Sprintf("• **%s** %s", strings.Title(field.Name), err.Error())
+		}
+		return ""
+	}
+
+	if err := b.validateTextFormat(field, value); err != nil {
+		return fmt.Sprintf("• **%s** %s", strings.Title(field.Name), err.Error())
+	}
+	return ""
+}
+
+// validateTextFormat guesses checks from the field name, for fields without
+// validate rules.
 func (b *Bot) validateTextFormat(field config.FieldSpec, value string) error {
 	fieldNameLower := strings.ToLower(field.Name)
 
@@ -283,6 +529,62 @@ func (b *Bot) validateTextFormat(field config.FieldSpec, value string) error {
 	return nil
 }
 
+// validationErrorMessage is the reply to input that failed validation.
+func validationErrorMessage(err error) string {
+	return fmt.Sprintf("❌ **Validation Error**\n\n%s\n\nPlease check your input and try again.", err.Error())
+}
+
+// deferResponse acknowledges an interaction so its response can be sent after
+// Discord's 3 second window, through editResponse or failDeferred.
+func (b *Bot) deferResponse(s *discordgo.Session, i *discordgo.InteractionCreate, ephemeral bool) error {
+	var flags discordgo.MessageFlags
+	if ephemeral {
+		flags = discordgo.MessageFlagsEphemeral
+	}
+
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: flags,
+		},
+	})
+}
+
+// editResponse replaces the "thinking" placeholder of a deferred response.
+// Mentions in the content never ping anyone, and content beyond Discord's
+// limit is cut off rather than failing the edit.
+func (b *Bot) editResponse(s *discordgo.Session, i *discordgo.InteractionCreate, content string, components []discordgo.MessageComponent) {
+	content = truncate(content, maxMessageContent)
+	edit := &discordgo.WebhookEdit{
+		Content:         &content,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+	if components != nil {
+		edit.Components = &components
+	}
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, edit); err != nil {
+		log.Printf("Error editing interaction response: %v", err)
+	}
+}
+
+// failDeferred replaces a deferred response with an error only the user can
+// see. A deferred response keeps the visibility it was deferred with, so the
+// placeholder is deleted and the error sent as an ephemeral followup.
+func (b *Bot) failDeferred(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
+	if err := s.InteractionResponseDelete(i.Interaction); err != nil {
+		log.Printf("Error deleting deferred response: %v", err)
+	}
+
+	_, err := s.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
+		Content: message,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+	if err != nil {
+		log.Printf("Error sending error followup: %v", err)
+	}
+}
+
 func (b *Bot) respondWithError(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
 	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -1,14 +1,19 @@
 package discord
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
 )
 
-func TestValidateFormData_RequiredFields(t *testing.T) {
+func TestValidateLocalValues_RequiredFields(t *testing.T) {
 	bot := &Bot{}
 
 	cmd := &config.CommandSpec{
@@ -22,47 +27,47 @@ func TestValidateFormData_RequiredFields(t *testing.T) {
 
 	tests := []struct {
 		name      string
-		formData  map[string]string
+		formData  map[string]interface{}
 		shouldErr bool
 	}{
 		{
 			name:      "valid data",
-			formData:  map[string]string{"title": "Test Title", "amount": "100"},
+			formData:  map[string]interface{}{"title": "Test Title", "amount": "100"},
 			shouldErr: false,
 		},
 		{
 			name:      "missing required title",
-			formData:  map[string]string{"amount": "100"},
+			formData:  map[string]interface{}{"amount": "100"},
 			shouldErr: true,
 		},
 		{
 			name:      "empty required title",
-			formData:  map[string]string{"title": "", "amount": "100"},
+			formData:  map[string]interface{}{"title": "", "amount": "100"},
 			shouldErr: true,
 		},
 		{
 			name:      "whitespace only title",
-			formData:  map[string]string{"title": "   ", "amount": "100"},
+			formData:  map[string]interface{}{"title": "   ", "amount": "100"},
 			shouldErr: true,
 		},
 		{
 			name:      "missing optional field",
-			formData:  map[string]string{"title": "Test Title", "amount": "100"},
+			formData:  map[string]interface{}{"title": "Test Title", "amount": "100"},
 			shouldErr: false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := bot.validateFormData(cmd, tt.formData)
+			err := bot.validateLocalValues(cmd, tt.formData)
 			if (err != nil) != tt.shouldErr {
-				t.Errorf("validateFormData() error = %v, shouldErr %v", err, tt.shouldErr)
+				t.Errorf("validateLocalValues() error = %v, shouldErr %v", err, tt.shouldErr)
 			}
 		})
 	}
 }
 
-func TestValidateFormData_SelectFields(t *testing.T) {
+func TestValidateLocalValues_SelectFields(t *testing.T) {
 	bot := &Bot{}
 
 	cmd := &config.CommandSpec{
@@ -75,42 +80,42 @@ func TestValidateFormData_SelectFields(t *testing.T) {
 
 	tests := []struct {
 		name      string
-		formData  map[string]string
+		formData  map[string]interface{}
 		shouldErr bool
 	}{
 		{
 			name:      "valid option",
-			formData:  map[string]string{"company": "Company A"},
+			formData:  map[string]interface{}{"company": "Company A"},
 			shouldErr: false,
 		},
 		{
 			name:      "invalid option",
-			formData:  map[string]string{"company": "Company C"},
+			formData:  map[string]interface{}{"company": "Company C"},
 			shouldErr: true,
 		},
 		{
 			name:      "empty required selection",
-			formData:  map[string]string{"company": ""},
+			formData:  map[string]interface{}{"company": ""},
 			shouldErr: true,
 		},
 		{
 			name:      "empty optional selection",
-			formData:  map[string]string{"company": "Company A", "optional_select": ""},
+			formData:  map[string]interface{}{"company": "Company A", "optional_select": ""},
 			shouldErr: false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := bot.validateFormData(cmd, tt.formData)
+			err := bot.validateLocalValues(cmd, tt.formData)
 			if (err != nil) != tt.shouldErr {
-				t.Errorf("validateFormData() error = %v, shouldErr %v", err, tt.shouldErr)
+				t.Errorf("validateLocalValues() error = %v, shouldErr %v", err, tt.shouldErr)
 			}
 		})
 	}
 }
 
-func TestValidateFormData_AttachmentFields(t *testing.T) {
+func TestValidateLocalValues_AttachmentFields(t *testing.T) {
 	bot := &Bot{}
 
 	cmd := &config.CommandSpec{
@@ -123,42 +128,42 @@ func TestValidateFormData_AttachmentFields(t *testing.T) {
 
 	tests := []struct {
 		name      string
-		formData  map[string]string
+		formData  map[string]interface{}
 		shouldErr bool
 	}{
 		{
 			name:      "valid attachment",
-			formData:  map[string]string{"pdf": "file.pdf"},
+			formData:  map[string]interface{}{"pdf": "file.pdf"},
 			shouldErr: false,
 		},
 		{
 			name:      "missing required attachment",
-			formData:  map[string]string{},
+			formData:  map[string]interface{}{},
 			shouldErr: true,
 		},
 		{
 			name:      "empty required attachment",
-			formData:  map[string]string{"pdf": ""},
+			formData:  map[string]interface{}{"pdf": ""},
 			shouldErr: true,
 		},
 		{
 			name:      "missing optional attachment",
-			formData:  map[string]string{"pdf": "file.pdf"},
+			formData:  map[string]interface{}{"pdf": "file.pdf"},
 			shouldErr: false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := bot.validateFormData(cmd, tt.formData)
+			err := bot.validateLocalValues(cmd, tt.formData)
 			if (err != nil) != tt.shouldErr {
-				t.Errorf("validateFormData() error = %v, shouldErr %v", err, tt.shouldErr)
+				t.Errorf("validateLocalValues() error = %v, shouldErr %v", err, tt.shouldErr)
 			}
 		})
 	}
 }
 
-func TestValidateFormData_MultipleErrors(t *testing.T) {
+func TestValidateLocalValues_MultipleErrors(t *testing.T) {
 	bot := &Bot{}
 
 	cmd := &config.CommandSpec{
@@ -170,13 +175,13 @@ func TestValidateFormData_MultipleErrors(t *testing.T) {
 		},
 	}
 
-	formData := map[string]string{
+	formData := map[string]interface{}{
 		"title":   "",
 		"company": "Invalid Company",
 		"pdf":     "",
 	}
 
-	err := bot.validateFormData(cmd, formData)
+	err := bot.validateLocalValues(cmd, formData)
 	if err == nil {
 		t.Error("Expected validation error, got nil")
 	}
@@ -198,7 +203,7 @@ func TestCreateFormResponse(t *testing.T) {
 
 	cmd := &config.CommandSpec{
 		Name:    "cost",
-		Webhook: "https://example.com/webhook",
+		Webhook: config.WebhookSpec{URL: "https://example.com/webhook"},
 		Fields: []config.FieldSpec{
 			{Name: "title", Type: "text", Required: true},
 			{Name: "amount", Type: "text", Required: true},
@@ -210,7 +215,7 @@ func TestCreateFormResponse(t *testing.T) {
 		"amount": "100",
 	}
 
-	response := bot.createFormResponse(cmd, formData, nil)
+	response := bot.createFormResponse(cmd, formData, []deliveryResult{{target: cmd.Targets()[0], attempts: 1}})
 
 	if !strings.Contains(response, "Form Successfully Submitted") {
 		t.Error("Expected response to contain success message")
@@ -237,7 +242,7 @@ func TestCreateFormResponse_WebhookError(t *testing.T) {
 
 	cmd := &config.CommandSpec{
 		Name:    "cost",
-		Webhook: "https://example.com/webhook",
+		Webhook: config.WebhookSpec{URL: "https://example.com/webhook"},
 		Fields: []config.FieldSpec{
 			{Name: "title", Type: "text", Required: true},
 		},
@@ -248,7 +253,7 @@ func TestCreateFormResponse_WebhookError(t *testing.T) {
 	}
 
 	webhookError := fmt.Errorf("connection failed")
-	response := bot.createFormResponse(cmd, formData, webhookError)
+	response := bot.createFormResponse(cmd, formData, []deliveryResult{{target: cmd.Targets()[0], attempts: 1, err: webhookError}})
 
 	if !strings.Contains(response, "Form Successfully Submitted") {
 		t.Error("Expected response to contain success message")
@@ -273,10 +278,28 @@ func TestCreateModalComponents(t *testing.T) {
 		},
 	}
 
-	components := bot.createModalComponents(cmd)
+	components, err := bot.createModalComponents(cmd)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(components) != 3 {
+		t.Fatalf("Expected 3 components, got %d", len(components))
+	}
 
-	if len(components) != 2 {
-		t.Errorf("Expected 2 components (only text fields), got %d", len(components))
+	label, ok := components[2].(modalLabel)
+	if !ok {
+		t.Fatalf("Expected select field to be wrapped in a label, got %T", components[2])
+	}
+	menu, ok := label.Component.(modalStringSelect)
+	if !ok {
+		t.Fatalf("Expected label to wrap a string select, got %T", label.Component)
+	}
+	if menu.CustomID != "company" || len(menu.Options) != 2 {
+		t.Errorf("Expected company select with 2 options, got %s with %d", menu.CustomID, len(menu.Options))
+	}
+	if menu.MinValues != 1 || menu.MaxValues != 1 || !menu.Required {
+		t.Errorf("Expected required single select, got min %d max %d required %v", menu.MinValues, menu.MaxValues, menu.Required)
 	}
 }
 
@@ -365,7 +388,7 @@ func TestValidateTextFormat(t *testing.T) {
 	}
 }
 
-func TestValidateFormData_RequiredFalse(t *testing.T) {
+func TestValidateLocalValues_RequiredFalse(t *testing.T) {
 	bot := &Bot{}
 
 	cmd := &config.CommandSpec{
@@ -378,12 +401,177 @@ func TestValidateFormData_RequiredFalse(t *testing.T) {
 		},
 	}
 
-	formData := map[string]string{
+	formData := map[string]interface{}{
 		"title": "Required Title",
 	}
 
-	err := bot.validateFormData(cmd, formData)
+	err := bot.validateLocalValues(cmd, formData)
 	if err != nil {
 		t.Errorf("Expected no error for optional fields, got: %v", err)
 	}
 }
+
+func TestValidateLocalValues_Rules(t *testing.T) {
+	bot := &Bot{}
+
+	cmd := &config.CommandSpec{
+		Name: "test",
+		Fields: []config.FieldSpec{
+			{Name: "email_notes", Type: "textarea", Validate: &config.ValidationRules{}},
+			{Name: "ticket", Type: "text", Validate: &config.ValidationRules{Pattern: `[A-Z]+-\d+`, Message: "must look like ABC-123"}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		formData  map[string]interface{}
+		shouldErr bool
+	}{
+		{"rules replace name guesses", map[string]interface{}{"email_notes": "not an email"}, false},
+		{"pattern matches", map[string]interface{}{"ticket": "OPS-42"}, false},
+		{"pattern fails", map[string]interface{}{"ticket": "ops 42"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := bot.validateLocalValues(cmd, tt.formData)
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("validateLocalValues() error = %v, shouldErr %v", err, tt.shouldErr)
+			}
+		})
+	}
+
+	err := bot.validateLocalValues(cmd, map[string]interface{}{"ticket": "nope"})
+	if err == nil || !strings.Contains(err.Error(), "must look like ABC-123") {
+		t.Errorf("Expected custom message, got %v", err)
+	}
+}
+
+func TestValidateSlashOptions(t *testing.T) {
+	bot := &Bot{}
+
+	cmd := &config.CommandSpec{
+		Name: "test",
+		Fields: []config.FieldSpec{
+			{Name: "contact", Type: "text", Validate: &config.ValidationRules{Format: "email"}},
+			{Name: "email_notes", Type: "text"},
+		},
+	}
+
+	option := func(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+	}
+
+	if err := bot.validateSlashOptions(cmd, []*discordgo.ApplicationCommandInteractionDataOption{
+		option("contact", "jane@example.com"),
+		option("email_notes", "anything goes"),
+	}); err != nil {
+		t.Errorf("Expected valid options, got %v", err)
+	}
+
+	if err := bot.validateSlashOptions(cmd, []*discordgo.ApplicationCommandInteractionDataOption{
+		option("contact", "jane"),
+	}); err == nil {
+		t.Error("Expected invalid email to be rejected")
+	}
+}
+
+func TestValidateFormValues_RemoteChecksAreSeparate(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `[{"label": "Engineering", "value": "eng"}]`)
+	}))
+	defer server.Close()
+
+	bot := &Bot{}
+
+	cmd := &config.CommandSpec{
+		Name: "test",
+		Fields: []config.FieldSpec{
+			{Name: "team", Type: "remote_select", Webhook: config.WebhookSpec{URL: server.URL}, Required: true},
+		},
+	}
+
+	if err := bot.validateLocalValues(cmd, map[string]interface{}{"team": "ops"}); err != nil {
+		t.Errorf("Expected local checks to pass, got %v", err)
+	}
+	if err := bot.validateLocalValues(cmd, map[string]interface{}{}); err == nil {
+		t.Error("Expected missing required value to fail local checks")
+	}
+	if requests != 0 {
+		t.Errorf("Expected local checks not to call the remote webhook, got %d requests", requests)
+	}
+
+	if err := bot.validateRemoteValues(cmd, map[string]interface{}{"team": "ops"}); err == nil {
+		t.Error("Expected unknown remote value to be rejected")
+	}
+	if err := bot.validateRemoteValues(cmd, map[string]interface{}{"team": "eng"}); err != nil {
+		t.Errorf("Expected known remote value to pass, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 remote lookups, got %d", requests)
+	}
+}
+
+func TestValidateRemoteValues_SlashOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"label": "Engineering", "value": "eng"}]`)
+	}))
+	defer server.Close()
+
+	bot := &Bot{}
+	cmd := &config.CommandSpec{
+		Name: "assign",
+		Type: "slash",
+		Fields: []config.FieldSpec{
+			{Name: "team", Type: "remote_select", Webhook: config.WebhookSpec{URL: server.URL}},
+			{Name: "count", Type: "integer"},
+		},
+	}
+
+	// Autocomplete lets users submit free text instead of a suggestion
+	typed := []*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "team", Type: discordgo.ApplicationCommandOptionString, Value: "anything I like"},
+		{Name: "count", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(3)},
+	}
+	if err := bot.validateRemoteValues(cmd, slashOptionValues(typed)); err == nil {
+		t.Error("Expected a typed value missing from the remote list to be rejected")
+	}
+
+	picked := []*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "team", Type: discordgo.ApplicationCommandOptionString, Value: "eng"},
+	}
+	if err := bot.validateRemoteValues(cmd, slashOptionValues(picked)); err != nil {
+		t.Errorf("Expected a listed value to pass, got %v", err)
+	}
+}
+
+func TestValidateRemoteValues_LabelFilteringEndpoint(t *testing.T) {
+	options := []config.RemoteOption{{Label: "Engineering", Value: "42"}, {Label: "Marketing", Value: "43"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := strings.ToLower(r.URL.Query().Get("query"))
+		var matches []config.RemoteOption
+		for _, option := range options {
+			if strings.Contains(strings.ToLower(option.Label), query) {
+				matches = append(matches, option)
+			}
+		}
+		json.NewEncoder(w).Encode(matches)
+	}))
+	defer server.Close()
+
+	bot := &Bot{}
+	cmd := &config.CommandSpec{
+		Name:   "assign",
+		Type:   "slash",
+		Fields: []config.FieldSpec{{Name: "team", Type: "remote_select", Webhook: config.WebhookSpec{URL: server.URL}}},
+	}
+
+	if err := bot.validateRemoteValues(cmd, map[string]interface{}{"team": "42"}); err != nil {
+		t.Errorf("Expected a listed value to pass, got %v", err)
+	}
+	if err := bot.validateRemoteValues(cmd, map[string]interface{}{"team": "Engineering"}); err == nil {
+		t.Error("Expected a label to be rejected as a value")
+	}
+}
@@ -0,0 +1,295 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// labelComponent is Discord's label component, which wraps a single input
+	// inside a modal. discordgo does not know it yet.
+	labelComponent discordgo.ComponentType = 18
+
+	maxSelectMenuOptions = 25
+
+	// modalOptionsTimeout keeps the remote_select lookups for a modal, which
+	// run at the same time, within Discord's 3 second window for opening it.
+	modalOptionsTimeout = 2500 * time.Millisecond
+)
+
+// modalLabel is a label component wrapping an input inside a modal.
+type modalLabel struct {
+	Label       string
+	Description string
+	Component   discordgo.MessageComponent
+}
+
+// Type is a method to get the type of a component.
+func (modalLabel) Type() discordgo.ComponentType {
+	return labelComponent
+}
+
+// MarshalJSON is a method for marshaling modalLabel to a JSON object.
+func (l modalLabel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type        discordgo.ComponentType    `json:"type"`
+		Label       string                     `json:"label"`
+		Description string                     `json:"description,omitempty"`
+		Component   discordgo.MessageComponent `json:"component"`
+	}{labelComponent, l.Label, l.Description, l.Component})
+}
+
+// modalStringSelect is a string select menu placed inside a modal label.
+// Unlike discordgo.SelectMenu it carries the required flag modals use.
+type modalStringSelect struct {
+	CustomID    string
+	Placeholder string
+	Options     []discordgo.SelectMenuOption
+	MinValues   int
+	MaxValues   int
+	Required    bool
+}
+
+// Type is a method to get the type of a component.
+func (modalStringSelect) Type() discordgo.ComponentType {
+	return discordgo.SelectMenuComponent
+}
+
+// MarshalJSON is a method for marshaling modalStringSelect to a JSON object.
+func (m modalStringSelect) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type        discordgo.ComponentType      `json:"type"`
+		CustomID    string                       `json:"custom_id"`
+		Placeholder string                       `json:"placeholder,omitempty"`
+		Options     []discordgo.SelectMenuOption `json:"options"`
+		MinValues   int                          `json:"min_values"`
+		MaxValues   int                          `json:"max_values"`
+		Required    bool                         `json:"required"`
+	}{discordgo.SelectMenuComponent, m.CustomID, m.Placeholder, m.Options, m.MinValues, m.MaxValues, m.Required})
+}
+
+// fetchModalOptions loads the options of every remote_select field in
+// fields at the same time, under one shared deadline, and returns them by
+// field name.
+func (b *Bot) fetchModalOptions(fields []config.FieldSpec) (map[string][]config.RemoteOption, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), modalOptionsTimeout)
+	defer cancel()
+
+	type lookup struct {
+		options []config.RemoteOption
+		err     error
+	}
+	lookups := make([]lookup, len(fields))
+
+	var wg sync.WaitGroup
+	for j, field := range fields {
+		if field.Type != "remote_select" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lookups[j].options, lookups[j].err = b.fetchRemoteOptions(ctx, field.Webhook, "")
+		}()
+	}
+	wg.Wait()
+
+	options := make(map[string][]config.RemoteOption)
+	for j, field := range fields {
+		if field.Type != "remote_select" {
+			continue
+		}
+		if lookups[j].err != nil {
+			return nil, fmt.Errorf("failed to create select menu for field %s: %w", field.Name, lookups[j].err)
+		}
+		options[field.Name] = lookups[j].options
+	}
+	return options, nil
+}
+
+// createSelectLabel renders a select or remote_select field as a labelled
+// string select menu, with the remote options fetched for it when the modal
+// opens. Remote options whose value is longer than Discord allows are left
+// out, since a single one would make Discord refuse the whole modal.
+func createSelectLabel(field config.FieldSpec, remoteOptions []config.RemoteOption) (modalLabel, error) {
+	var options []discordgo.SelectMenuOption
+
+	switch field.Type {
+	case "select":
+		for _, option := range field.Options {
+			options = append(options, discordgo.SelectMenuOption{Label: option, Value: option})
+		}
+	case "remote_select":
+		for _, option := range remoteOptions {
+			if utf8.RuneCountInString(option.Value) > maxChoiceLength {
+				log.Printf("Warning: remote_select field %s skips option %q, whose value is longer than %d characters", field.Name, truncate(option.Label, maxChoiceLength), maxChoiceLength)
+				continue
+			}
+			if len(options) == maxSelectMenuOptions {
+				log.Printf("Warning: remote_select field %s returned %d options, only the first %d are shown", field.Name, len(remoteOptions), maxSelectMenuOptions)
+				break
+			}
+			options = append(options, discordgo.SelectMenuOption{
+				Label: truncate(option.Label, maxChoiceLength),
+				Value: option.Value,
+			})
+		}
+		if len(options) == 0 {
+			return modalLabel{}, fmt.Errorf("remote webhook returned no options")
+		}
+	}
+
+	minValues, maxValues := selectBounds(field, len(options))
+
+	return modalLabel{
+		Label: strings.Title(field.Name),
+		Component: modalStringSelect{
+			CustomID:    field.Name,
+			Placeholder: fmt.Sprintf("Select %s", field.Name),
+			Options:     options,
+			MinValues:   minValues,
+			MaxValues:   maxValues,
+			Required:    field.Required,
+		},
+	}, nil
+}
+
+// selectBounds returns how many options a select menu accepts. By default a
+// required field needs one selection and an optional one none, and a single
+// option may be selected.
+func selectBounds(field config.FieldSpec, optionCount int) (int, int) {
+	minValues := 0
+	if field.Required {
+		minValues = 1
+	}
+	if field.MinValues != nil {
+		minValues = *field.MinValues
+	}
+
+	maxValues := 1
+	if field.MaxValues != nil {
+		maxValues = *field.MaxValues
+	}
+	if maxValues > optionCount {
+		maxValues = optionCount
+	}
+	if minValues > maxValues {
+		minValues = maxValues
+	}
+
+	return minValues, maxValues
+}
+
+// rawComponent is a submitted modal component as sent by Discord.
+type rawComponent struct {
+	Type       discordgo.ComponentType `json:"type"`
+	CustomID   string                  `json:"custom_id"`
+	Value      *string                 `json:"value"`
+	Values     []string                `json:"values"`
+	Component  *rawComponent           `json:"component"`
+	Components []rawComponent          `json:"components"`
+}
+
+// handleRawModalSubmit handles modal submissions discordgo failed to decode,
+// which happens when the modal contained label components. The form values
+// are read from the raw gateway event instead.
+func (b *Bot) handleRawModalSubmit(s *discordgo.Session, e *discordgo.Event) {
+	if e.Type != "INTERACTION_CREATE" {
+		return
+	}
+
+	i, ok := e.Struct.(*discordgo.InteractionCreate)
+	if !ok || i.Interaction == nil || i.Type != discordgo.InteractionModalSubmit || i.Data != nil {
+		return
+	}
+
+	customID, formData, err := parseRawModalSubmit(e.RawData)
+	if err != nil {
+		log.Printf("Error decoding modal submission: %v", err)
+		return
+	}
+
+	b.processModalSubmit(s, i, customID, formData)
+}
+
+// parseRawModalSubmit extracts the modal's custom ID and the submitted values
+// from a raw interaction: strings for text inputs and string slices for
+// select menus.
+func parseRawModalSubmit(raw json.RawMessage) (string, map[string]interface{}, error) {
+	var interaction struct {
+		Data struct {
+			CustomID   string         `json:"custom_id"`
+			Components []rawComponent `json:"components"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &interaction); err != nil {
+		return "", nil, err
+	}
+
+	formData := make(map[string]interface{})
+	collectRawValues(interaction.Data.Components, formData)
+
+	return interaction.Data.CustomID, formData, nil
+}
+
+func collectRawValues(components []rawComponent, formData map[string]interface{}) {
+	for _, component := range components {
+		switch {
+		case component.Component != nil:
+			collectRawValues([]rawComponent{*component.Component}, formData)
+		case len(component.Components) > 0:
+			collectRawValues(component.Components, formData)
+		case component.Value != nil:
+			formData[component.CustomID] = *component.Value
+		case component.CustomID != "":
+			values := component.Values
+			if values == nil {
+				values = []string{}
+			}
+			formData[component.CustomID] = values
+		}
+	}
+}
+
+// formValueStrings returns the non-blank values of a submitted field.
+func formValueStrings(value interface{}) []string {
+	var values []string
+
+	switch v := value.(type) {
+	case nil:
+	case string:
+		values = []string{v}
+	case []string:
+		values = v
+	default:
+		values = []string{fmt.Sprint(v)}
+	}
+
+	nonBlank := make([]string, 0, len(values))
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			nonBlank = append(nonBlank, v)
+		}
+	}
+	return nonBlank
+}
+
+// displayFormData flattens submitted values for display, joining the
+// selections of select menus.
+func displayFormData(formData map[string]interface{}) map[string]string {
+	display := make(map[string]string, len(formData))
+	for name, value := range formData {
+		display[name] = strings.Join(formValueStrings(value), ", ")
+	}
+	return display
+}
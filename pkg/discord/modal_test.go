@@ -0,0 +1,156 @@
+package discord
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"yambot/pkg/config"
+)
+
+func TestParseRawModalSubmit(t *testing.T) {
+	raw := json.RawMessage(`{
+		"type": 5,
+		"data": {
+			"custom_id": "modal_feedback",
+			"components": [
+				{"type": 1, "components": [{"type": 4, "custom_id": "subject", "value": "Hello"}]},
+				{"type": 18, "id": 2, "component": {"type": 3, "custom_id": "topics", "values": ["Bug", "Idea"]}},
+				{"type": 18, "id": 3, "component": {"type": 3, "custom_id": "team", "values": []}},
+				{"type": 18, "id": 4, "component": {"type": 4, "custom_id": "message", "value": ""}}
+			]
+		}
+	}`)
+
+	customID, formData, err := parseRawModalSubmit(raw)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if customID != "modal_feedback" {
+		t.Errorf("Expected custom ID modal_feedback, got %s", customID)
+	}
+
+	expected := map[string]interface{}{
+		"subject": "Hello",
+		"topics":  []string{"Bug", "Idea"},
+		"team":    []string{},
+		"message": "",
+	}
+	if !reflect.DeepEqual(formData, expected) {
+		t.Errorf("Expected %v, got %v", expected, formData)
+	}
+}
+
+func TestValidateLocalValues_MultiSelect(t *testing.T) {
+	bot := &Bot{}
+
+	cmd := &config.CommandSpec{
+		Name: "test",
+		Fields: []config.FieldSpec{
+			{Name: "topics", Type: "select", Options: []string{"Bug", "Idea", "Other"}, Required: true},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		values    interface{}
+		shouldErr bool
+	}{
+		{"several valid values", []string{"Bug", "Idea"}, false},
+		{"one invalid value", []string{"Bug", "Feature"}, true},
+		{"no selection", []string{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := bot.validateLocalValues(cmd, map[string]interface{}{"topics": tt.values})
+			if (err != nil) != tt.shouldErr {
+				t.Errorf("validateLocalValues() error = %v, shouldErr %v", err, tt.shouldErr)
+			}
+		})
+	}
+}
+
+func TestSelectBounds(t *testing.T) {
+	one, three := 1, 3
+
+	tests := []struct {
+		name     string
+		field    config.FieldSpec
+		options  int
+		min, max int
+	}{
+		{"optional default", config.FieldSpec{}, 4, 0, 1},
+		{"required default", config.FieldSpec{Required: true}, 4, 1, 1},
+		{"configured", config.FieldSpec{MinValues: &one, MaxValues: &three}, 4, 1, 3},
+		{"capped by options", config.FieldSpec{MinValues: &three, MaxValues: &three}, 2, 2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minValues, maxValues := selectBounds(tt.field, tt.options)
+			if minValues != tt.min || maxValues != tt.max {
+				t.Errorf("Expected %d-%d, got %d-%d", tt.min, tt.max, minValues, maxValues)
+			}
+		})
+	}
+}
+
+func TestCreateModalComponents_ConcurrentRemoteSelects(t *testing.T) {
+	// Each endpoint takes most of the shared deadline, so the modal only
+	// opens in time when both are asked at once
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(modalOptionsTimeout * 2 / 3)
+		fmt.Fprint(w, `[{"label": "Engineering", "value": "eng"}]`)
+	}))
+	defer slow.Close()
+
+	bot := &Bot{}
+	cmd := &config.CommandSpec{
+		Name: "assign",
+		Type: "modal",
+		Fields: []config.FieldSpec{
+			{Name: "team", Type: "remote_select", Webhook: config.WebhookSpec{URL: slow.URL}},
+			{Name: "reviewer", Type: "remote_select", Webhook: config.WebhookSpec{URL: slow.URL + "/reviewers"}},
+		},
+	}
+
+	start := time.Now()
+	components, err := bot.createModalComponents(cmd)
+	if err != nil {
+		t.Fatalf("Expected the modal to open, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= modalOptionsTimeout {
+		t.Errorf("Expected the lookups to share the %v deadline, took %v", modalOptionsTimeout, elapsed)
+	}
+	if len(components) != 2 {
+		t.Errorf("Expected 2 select menus, got %d", len(components))
+	}
+}
+
+func TestCreateSelectLabel_LongRemoteValues(t *testing.T) {
+	field := config.FieldSpec{Name: "ticket", Type: "remote_select"}
+	remoteOptions := []config.RemoteOption{
+		{Label: "Too long", Value: strings.Repeat("x", 101)},
+		{Label: "Fine", Value: "t-1"},
+	}
+
+	label, err := createSelectLabel(field, remoteOptions)
+	if err != nil {
+		t.Fatalf("Failed to create select menu: %v", err)
+	}
+	options := label.Component.(modalStringSelect).Options
+	if len(options) != 1 || options[0].Value != "t-1" {
+		t.Errorf("Expected only the option with a short value, got %+v", options)
+	}
+
+	if _, err := createSelectLabel(field, remoteOptions[:1]); err == nil {
+		t.Error("Expected an error when no option can be shown")
+	}
+}
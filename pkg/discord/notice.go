@@ -0,0 +1,67 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// postNotice posts the command's public notice of a submission to its
+// notice channel, if it has one. Only the fields the notice lists are
+// available to it. Failures are logged, as the submission itself went
+// through.
+func (b *Bot) postNotice(s *discordgo.Session, cmd *config.CommandSpec, i *discordgo.InteractionCreate, display map[string]string, results []deliveryResult) {
+	if cmd.Notice == nil {
+		return
+	}
+
+	message, err := noticeMessage(cmd, i, display, results)
+	if err != nil {
+		log.Printf("Error rendering notice for %s: %v", cmd.Name, err)
+		return
+	}
+
+	_, err = s.ChannelMessageSendComplex(cmd.Notice.Channel, &discordgo.MessageSend{
+		Content:         message,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		log.Printf("Error posting notice for %s to channel %s: %v", cmd.Name, cmd.Notice.Channel, err)
+	}
+}
+
+// noticeMessage renders the notice of a submission, with the values of
+// fields the notice does not list left out.
+func noticeMessage(cmd *config.CommandSpec, i *discordgo.InteractionCreate, display map[string]string, results []deliveryResult) (string, error) {
+	notice := cmd.Notice
+
+	data := responseData(cmd, i, display)
+	data.Delivery = deliveryData(results)
+	redacted := make(map[string]string, len(notice.Fields))
+	for _, name := range notice.Fields {
+		redacted[name] = data.Fields[name]
+	}
+	data.Fields = redacted
+
+	if notice.Message != "" {
+		message, err := notice.Render(data)
+		if err != nil {
+			return "", err
+		}
+		return truncate(message, maxMessageContent), nil
+	}
+
+	message := fmt.Sprintf("📬 New **%s** submission", strings.Title(cmd.Name))
+	for _, name := range notice.Fields {
+		value := data.Fields[name]
+		if strings.TrimSpace(value) == "" {
+			value = "*Not provided*"
+		}
+		message += fmt.Sprintf("\n**%s**: %s", strings.Title(name), value)
+	}
+	return truncate(message, maxMessageContent), nil
+}
@@ -0,0 +1,61 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+
+	"yambot/pkg/config"
+)
+
+func TestNoticeMessage(t *testing.T) {
+	cmd := &config.CommandSpec{
+		Name:   "feedback",
+		Notice: &config.NoticeSpec{Channel: "123456789012345678", Fields: []string{"topic", "rating"}},
+		Fields: []config.FieldSpec{
+			{Name: "topic", Type: "text"},
+			{Name: "rating", Type: "text"},
+			{Name: "message", Type: "textarea"},
+		},
+	}
+	display := map[string]string{"topic": "Canteen", "message": "The soup was cold"}
+
+	message, err := noticeMessage(cmd, testInteraction(), display, nil)
+	if err != nil {
+		t.Fatalf("Expected notice to render, got %v", err)
+	}
+
+	want := "📬 New **Feedback** submission\n**Topic**: Canteen\n**Rating**: *Not provided*"
+	if message != want {
+		t.Errorf("Expected '%s', got '%s'", want, message)
+	}
+}
+
+func TestNoticeMessage_Template(t *testing.T) {
+	cmd := &config.CommandSpec{
+		Name: "feedback",
+		Notice: &config.NoticeSpec{
+			Channel: "123456789012345678",
+			Message: "New feedback about {{ .Fields.topic }}{{ .Fields.message }}",
+			Fields:  []string{"topic"},
+		},
+		Fields: []config.FieldSpec{
+			{Name: "topic", Type: "text"},
+			{Name: "message", Type: "textarea"},
+		},
+	}
+	display := map[string]string{"topic": "Canteen", "message": "The soup was cold"}
+
+	message, err := noticeMessage(cmd, testInteraction(), display, nil)
+	if err != nil {
+		t.Fatalf("Expected notice to render, got %v", err)
+	}
+
+	// Fields the notice does not list are redacted, even when the template
+	// refers to them
+	if strings.Contains(message, "soup") {
+		t.Errorf("Expected unlisted fields to be left out, got '%s'", message)
+	}
+	if message != "New feedback about Canteen" {
+		t.Errorf("Expected 'New feedback about Canteen', got '%s'", message)
+	}
+}
@@ -0,0 +1,148 @@
+package discord
+
+import (
+	"log"
+	"time"
+
+	"yambot/pkg/config"
+	"yambot/pkg/outbox"
+)
+
+// outboxLeaseMargin is added to the retry window of a delivery to get how long
+// its outbox entry is kept from the background worker.
+const outboxLeaseMargin = time.Minute
+
+// queuedError is a failed delivery whose payload stays in the outbox, to be
+// retried in the background.
+type queuedError struct {
+	err error
+}
+
+func (e *queuedError) Error() string {
+	return e.err.Error() + " (queued for redelivery)"
+}
+
+func (e *queuedError) Unwrap() error {
+	return e.err
+}
+
+// deliverWebhook sends a payload to one of a command's destinations with its
+// retry policy and returns the number of attempts made. With an outbox, the
+// payload is stored before the first attempt: it is removed once delivered,
+// kept for the background worker when the endpoint may still recover
+// (reported as a *queuedError), and dead-lettered otherwise. While the
+// attempts are running, the entry is not due, so the worker cannot send it a
+// second time.
+func (b *Bot) deliverWebhook(cmd *config.CommandSpec, target config.WebhookTarget, payload interface{}) (int, []byte, error) {
+	policy := b.currentConfig().RetryForTarget(*cmd, target)
+
+	body, contentType, err := encodePayload(payload)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if b.Outbox == nil {
+		attempts, reply, _, err := b.WebhookService.deliverBody(target.Webhook, body, contentType, policy, target.Secret)
+		return attempts, reply, err
+	}
+
+	entry := &outbox.Entry{
+		Command:     cmd.Name,
+		Target:      target.Name,
+		URL:         target.Webhook.URL,
+		NextAttempt: time.Now().Add(policy.Window(target.Webhook.RequestTimeout()) + outboxLeaseMargin),
+	}
+	if contentType == jsonContentType {
+		entry.Payload = body
+	} else {
+		entry.Body, entry.ContentType = body, contentType
+	}
+	if err := b.Outbox.Enqueue(entry); err != nil {
+		// Delivering without a safety net beats dropping the submission
+		log.Printf("Error storing webhook payload for %s in outbox: %v", cmd.Name, err)
+		attempts, reply, _, err := b.WebhookService.deliverBody(target.Webhook, body, contentType, policy, target.Secret)
+		return attempts, reply, err
+	}
+
+	attempts, reply, retryable, err := b.WebhookService.deliverBody(target.Webhook, body, contentType, policy, target.Secret)
+	if err == nil {
+		if err := b.Outbox.Delete(entry.ID); err != nil {
+			log.Printf("Error removing delivered outbox entry %s: %v", entry.ID, err)
+		}
+		return attempts, reply, nil
+	}
+
+	entry.Attempts = attempts
+	entry.LastError = err.Error()
+
+	if !retryable {
+		if buryErr := b.Outbox.Bury(*entry); buryErr != nil {
+			log.Printf("Error moving outbox entry %s to dead letters: %v", entry.ID, buryErr)
+		}
+		return attempts, nil, err
+	}
+
+	settings := b.currentConfig().Bot.Outbox.WithDefaults()
+	entry.NextAttempt = time.Now().Add(settings.Interval)
+	if rescheduleErr := b.Outbox.Reschedule(*entry); rescheduleErr != nil {
+		log.Printf("Error rescheduling outbox entry %s: %v", entry.ID, rescheduleErr)
+		return attempts, nil, err
+	}
+	return attempts, nil, &queuedError{err: err}
+}
+
+// outboxWorker returns the worker redelivering the outbox in the background.
+func (b *Bot) outboxWorker() *outbox.Worker {
+	settings := b.currentConfig().Bot.Outbox.WithDefaults()
+	return &outbox.Worker{
+		Store:       b.Outbox,
+		Deliver:     b.redeliver,
+		Interval:    settings.Interval,
+		MaxAttempts: settings.MaxAttempts,
+	}
+}
+
+// redeliver makes one background attempt for an outbox entry to the URL it was
+// queued for, using the current request settings, secret and retry policy of
+// the destination. Secrets and credentials are never stored in the outbox.
+func (b *Bot) redeliver(entry outbox.Entry) (bool, error) {
+	cfg := b.currentConfig()
+
+	cmd := config.CommandSpec{Name: entry.Command}
+	for _, candidate := range cfg.GetCommands() {
+		if candidate.Name == entry.Command {
+			cmd = candidate
+			break
+		}
+	}
+
+	target := outboxTarget(cmd, entry)
+	webhook := target.Webhook
+	webhook.URL = entry.URL
+
+	body, contentType := []byte(entry.Payload), jsonContentType
+	if entry.Body != nil {
+		body, contentType = entry.Body, entry.ContentType
+	}
+
+	_, retryable, _, err := b.WebhookService.postWebhook(webhook, body, contentType, cfg.RetryForTarget(cmd, target), target.Secret)
+	return retryable, err
+}
+
+// outboxTarget finds the destination an outbox entry was queued for, by name
+// and then by URL. Entries whose destination is gone are sent without any
+// request settings.
+func outboxTarget(cmd config.CommandSpec, entry outbox.Entry) config.WebhookTarget {
+	targets := cmd.Targets()
+	for _, target := range targets {
+		if entry.Target != "" && target.Name == entry.Target {
+			return target
+		}
+	}
+	for _, target := range targets {
+		if target.Webhook.URL == entry.URL {
+			return target
+		}
+	}
+	return config.WebhookTarget{}
+}
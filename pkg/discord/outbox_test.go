@@ -0,0 +1,169 @@
+package discord
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"yambot/pkg/config"
+	"yambot/pkg/outbox"
+)
+
+func TestDeliverWebhook_Outbox(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		wantErr     bool
+		wantQueued  bool
+		wantPending int
+		wantDead    int
+	}{
+		{"delivered", http.StatusOK, false, false, 0, 0},
+		{"endpoint down", http.StatusServiceUnavailable, true, true, 1, 0},
+		{"rejected payload", http.StatusBadRequest, true, false, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			store, err := outbox.Open(t.TempDir())
+			if err != nil {
+				t.Fatalf("Failed to open outbox: %v", err)
+			}
+			defer store.Close()
+
+			bot := &Bot{
+				Config:         &config.Config{},
+				WebhookService: NewWebhookService(),
+				Outbox:         store,
+			}
+			cmd := &config.CommandSpec{Name: "report", Webhook: config.WebhookSpec{URL: server.URL}}
+
+			attempts, _, err := bot.deliverWebhook(cmd, cmd.Targets()[0], map[string]interface{}{"title": "Hello"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			var queued *queuedError
+			if errors.As(err, &queued) != tt.wantQueued {
+				t.Errorf("Expected queued %v, got %v", tt.wantQueued, err)
+			}
+			if attempts != 1 {
+				t.Errorf("Expected 1 attempt, got %d", attempts)
+			}
+
+			pending, _ := store.Pending()
+			dead, _ := store.Dead()
+			if len(pending) != tt.wantPending || len(dead) != tt.wantDead {
+				t.Errorf("Expected %d pending and %d dead entries, got %d and %d", tt.wantPending, tt.wantDead, len(pending), len(dead))
+			}
+			if len(pending) == 1 && (pending[0].Attempts != 1 || string(pending[0].Payload) != `{"title":"Hello"}`) {
+				t.Errorf("Expected queued entry with payload and 1 attempt, got %+v", pending[0])
+			}
+		})
+	}
+}
+
+func TestDeliverWebhook_OutboxMultipart(t *testing.T) {
+	var contentType, body string
+	up := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		contentType = r.Header.Get("Content-Type")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	store, err := outbox.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open outbox: %v", err)
+	}
+	defer store.Close()
+
+	cmd := config.CommandSpec{Name: "upload", Webhook: config.WebhookSpec{URL: server.URL}}
+	bot := &Bot{
+		Config:         &config.Config{Commands: []config.CommandSpec{cmd}},
+		WebhookService: NewWebhookService(),
+		Outbox:         store,
+	}
+
+	payload := &encodedPayload{body: []byte("--b\r\n...\r\n--b--\r\n"), contentType: "multipart/form-data; boundary=b"}
+	if _, _, err := bot.deliverWebhook(&cmd, cmd.Targets()[0], payload); err == nil {
+		t.Fatal("Expected delivery to fail while the endpoint is down")
+	}
+
+	pending, _ := store.Pending()
+	if len(pending) != 1 || pending[0].Payload != nil || pending[0].ContentType != payload.contentType {
+		t.Fatalf("Expected queued multipart entry, got %+v", pending)
+	}
+
+	up = true
+	if _, err := bot.redeliver(pending[0]); err != nil {
+		t.Fatalf("Expected redelivery to succeed, got %v", err)
+	}
+	if contentType != payload.contentType || body != string(payload.body) {
+		t.Errorf("Expected the multipart body to be redelivered, got %s: %q", contentType, body)
+	}
+}
+
+func TestDeliverWebhook_OutboxFlushInFlight(t *testing.T) {
+	var hits atomic.Int32
+	received := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			close(received)
+			<-release
+		}
+	}))
+	defer server.Close()
+
+	store, err := outbox.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open outbox: %v", err)
+	}
+	defer store.Close()
+
+	bot := &Bot{
+		Config:         &config.Config{},
+		WebhookService: NewWebhookService(),
+		Outbox:         store,
+	}
+	cmd := &config.CommandSpec{Name: "report", Webhook: config.WebhookSpec{URL: server.URL}}
+
+	done := make(chan error)
+	go func() {
+		_, _, err := bot.deliverWebhook(cmd, cmd.Targets()[0], map[string]interface{}{"title": "Hello"})
+		done <- err
+	}()
+	<-received
+
+	// A worker tick while the foreground attempt is running must leave the
+	// entry alone
+	bot.outboxWorker().Flush(time.Now())
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Expected delivery to succeed, got %v", err)
+	}
+
+	if n := hits.Load(); n != 1 {
+		t.Errorf("Expected the payload to be sent once, got %d requests", n)
+	}
+	pending, _ := store.Pending()
+	dead, _ := store.Dead()
+	if len(pending) != 0 || len(dead) != 0 {
+		t.Errorf("Expected no pending or dead entries, got %d and %d", len(pending), len(dead))
+	}
+}
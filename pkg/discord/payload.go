@@ -0,0 +1,183 @@
+package discord
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// deliveryResult is the outcome of sending a submission to one destination.
+type deliveryResult struct {
+	target   config.WebhookTarget
+	attempts int
+	err      error
+	// reply is what the destination asked to show the user, if anything.
+	reply *webhookReply
+}
+
+// failed reports whether the delivery failed for good. Queued payloads are
+// still delivered later.
+func (r deliveryResult) failed() bool {
+	var queued *queuedError
+	return r.err != nil && !errors.As(r.err, &queued)
+}
+
+// submissionFailed reports whether a required destination failed.
+func submissionFailed(results []deliveryResult) bool {
+	for _, result := range results {
+		if result.target.IsRequired() && result.failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// submitWebhook sends the field values of a submission to each of the
+// command's destinations at the same time and returns their outcomes.
+// Uploaded files are downloaded once and sent along when the command's
+// attachment mode asks for it.
+func (b *Bot) submitWebhook(cmd *config.CommandSpec, i *discordgo.InteractionCreate, fields map[string]interface{}, files []attachmentFile) []deliveryResult {
+	targets := cmd.Targets()
+	results := make([]deliveryResult, len(targets))
+	for j, target := range targets {
+		results[j].target = target
+	}
+
+	if cmd.Attachments.Downloads() {
+		if err := b.downloadAttachments(cmd.Attachments, files); err != nil {
+			for j := range results {
+				results[j].err = err
+			}
+			return results
+		}
+		if cmd.Attachments.Mode == "base64" {
+			addBase64Attachments(fields, files)
+		}
+	}
+
+	legacy := b.currentConfig().LegacyPayloadFor(*cmd)
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	for j := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[j].attempts, results[j].reply, results[j].err = b.submitTo(cmd, results[j].target, legacy, i, fields, files, now)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// submitTo sends a submission to one destination and returns the number of
+// attempts made and the destination's reply. A reply with an error fails the
+// delivery.
+func (b *Bot) submitTo(cmd *config.CommandSpec, target config.WebhookTarget, legacy bool, i *discordgo.InteractionCreate, fields map[string]interface{}, files []attachmentFile, now time.Time) (int, *webhookReply, error) {
+	payload, err := webhookPayload(cmd, target.PayloadTemplate, legacy, i, fields, now)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if cmd.Attachments.Downloads() && cmd.Attachments.Mode == "forward" {
+		payload, err = multipartPayload(payload, files)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	attempts, body, err := b.deliverWebhook(cmd, target, payload)
+	if err != nil {
+		return attempts, nil, err
+	}
+
+	reply := parseWebhookReply(body)
+	if reply != nil && reply.Error != "" {
+		return attempts, reply, fmt.Errorf("webhook returned an error: %s", reply.Error)
+	}
+	return attempts, reply, nil
+}
+
+// webhookPayload returns the body of a webhook request: the rendered payload
+// template if there is one, the flat field values in legacy mode, and
+// otherwise the envelope with the interaction context and the values under
+// "fields".
+func webhookPayload(cmd *config.CommandSpec, tmpl *config.PayloadTemplate, legacy bool, i *discordgo.InteractionCreate, fields map[string]interface{}, now time.Time) (interface{}, error) {
+	if tmpl == nil {
+		if legacy {
+			return fields, nil
+		}
+		return payloadData(cmd, i, fields, now), nil
+	}
+
+	data := payloadData(cmd, i, fields, now)
+
+	// Templates get empty strings for fields left empty, so they do not
+	// print "<no value>"
+	for name, value := range data.Fields {
+		if value == nil {
+			data.Fields[name] = ""
+		}
+	}
+
+	body, err := tmpl.Render(data)
+	if err != nil {
+		log.Printf("Error rendering payload template for %s: %v", cmd.Name, err)
+		return nil, fmt.Errorf("failed to render payload template")
+	}
+	return json.RawMessage(body), nil
+}
+
+// payloadData describes a submission of cmd made through interaction i.
+func payloadData(cmd *config.CommandSpec, i *discordgo.InteractionCreate, fields map[string]interface{}, now time.Time) config.PayloadData {
+	data := config.PayloadData{
+		InteractionID: i.ID,
+		Command:       cmd.Name,
+		CommandType:   cmd.Type,
+		GuildID:       i.GuildID,
+		ChannelID:     i.ChannelID,
+		Locale:        string(i.Locale),
+		Timestamp:     now.UTC().Format(time.RFC3339),
+		User:          payloadUser(i),
+		Fields:        make(map[string]interface{}, len(fields)),
+	}
+
+	// Slash command values carry the command name, which the envelope
+	// already has, unless a field is named "command"
+	synthetic := findFieldSpec(cmd, "command") == nil
+	for name, value := range fields {
+		if name == "command" && synthetic {
+			continue
+		}
+		data.Fields[name] = value
+	}
+
+	return data
+}
+
+// payloadUser describes the user who made interaction i.
+func payloadUser(i *discordgo.InteractionCreate) config.PayloadUser {
+	payload := config.PayloadUser{Roles: []string{}}
+	if user := interactionUser(i); user != nil {
+		payload.ID = user.ID
+		payload.Username = user.Username
+		payload.DisplayName = user.DisplayName()
+	}
+	if i.Member != nil {
+		if i.Member.Nick != "" {
+			payload.DisplayName = i.Member.Nick
+		}
+		if i.Member.Roles != nil {
+			payload.Roles = i.Member.Roles
+		}
+	}
+	return payload
+}
@@ -0,0 +1,201 @@
+package discord
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func testInteraction() *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:        "999",
+		GuildID:   "456",
+		ChannelID: "789",
+		Locale:    discordgo.German,
+		Member: &discordgo.Member{
+			Nick:  "Ally",
+			Roles: []string{"111", "222"},
+			User:  &discordgo.User{ID: "123", Username: "alice", GlobalName: "Alice"},
+		},
+	}}
+}
+
+func TestWebhookPayload(t *testing.T) {
+	fields := map[string]interface{}{"command": "report", "title": "Printer on fire", "notes": nil}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cmd := &config.CommandSpec{Name: "report", Type: "slash"}
+
+	payload, err := webhookPayload(cmd, cmd.PayloadTemplate, true, testInteraction(), fields, now)
+	if err != nil {
+		t.Fatalf("Failed to build payload: %v", err)
+	}
+	if flat, ok := payload.(map[string]interface{}); !ok || flat["title"] != "Printer on fire" || flat["command"] != "report" {
+		t.Errorf("Expected flat field values in legacy mode, got %#v", payload)
+	}
+
+	payload, err = webhookPayload(cmd, cmd.PayloadTemplate, false, testInteraction(), fields, now)
+	if err != nil {
+		t.Fatalf("Failed to build payload: %v", err)
+	}
+	body, _ := json.Marshal(payload)
+	want := `{"interaction_id":"999","command":"report","command_type":"slash",` +
+		`"user":{"id":"123","username":"alice","display_name":"Ally","roles":["111","222"]},` +
+		`"guild_id":"456","channel_id":"789","locale":"de","timestamp":"2024-05-01T12:00:00Z",` +
+		`"fields":{"notes":null,"title":"Printer on fire"}}`
+	if string(body) != want {
+		t.Errorf("Expected envelope %s, got %s", want, body)
+	}
+
+	cmd.PayloadTemplate = &config.PayloadTemplate{
+		Text: `{"summary": "{{ .Fields.title }}", "notes": "{{ .Fields.notes }}", "by": "{{ .User.DisplayName }} ({{ .User.ID }})", "where": "{{ .GuildID }}/{{ .ChannelID }}", "at": "{{ .Timestamp }}", "has_command": {{ if .Fields.command }}true{{ else }}false{{ end }}}`,
+	}
+	payload, err = webhookPayload(cmd, cmd.PayloadTemplate, false, testInteraction(), fields, now)
+	if err != nil {
+		t.Fatalf("Failed to render payload: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(payload.(json.RawMessage), &got); err != nil {
+		t.Fatalf("Rendered payload is not JSON: %v", err)
+	}
+	wantFields := map[string]interface{}{
+		"summary":     "Printer on fire",
+		"notes":       "",
+		"by":          "Ally (123)",
+		"where":       "456/789",
+		"at":          "2024-05-01T12:00:00Z",
+		"has_command": false,
+	}
+	for key, value := range wantFields {
+		if got[key] != value {
+			t.Errorf("Expected %s %v, got %v", key, value, got[key])
+		}
+	}
+
+	cmd.PayloadTemplate = &config.PayloadTemplate{Text: `{"summary": {{ .Fields.title }}}`}
+	if _, err := webhookPayload(cmd, cmd.PayloadTemplate, false, testInteraction(), fields, now); err == nil {
+		t.Error("Expected error for a template producing invalid JSON")
+	}
+}
+
+func TestWebhookPayload_CommandField(t *testing.T) {
+	cmd := &config.CommandSpec{
+		Name:   "deploy",
+		Type:   "slash",
+		Fields: []config.FieldSpec{{Name: "command", Type: "text"}, {Name: "notes", Type: "text"}},
+	}
+	options := []*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "command", Type: discordgo.ApplicationCommandOptionString, Value: "restart"},
+	}
+	fields := slashCommandPayload(cmd, options, nil)
+
+	payload, err := webhookPayload(cmd, cmd.PayloadTemplate, false, testInteraction(), fields, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to build payload: %v", err)
+	}
+	data := payload.(config.PayloadData)
+	if data.Command != "deploy" || data.Fields["command"] != "restart" {
+		t.Errorf("Expected the command field in the envelope, got %+v", data)
+	}
+
+	fields = slashCommandPayload(cmd, nil, nil)
+	if value, ok := fields["command"]; !ok || value != nil {
+		t.Errorf("Expected an empty command field to be sent as null, got %#v", value)
+	}
+}
+
+func TestWebhookPayload_DirectMessage(t *testing.T) {
+	i := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:        "999",
+		ChannelID: "789",
+		User:      &discordgo.User{ID: "123", Username: "alice"},
+	}}
+	cmd := &config.CommandSpec{Name: "feedback", Type: "modal"}
+
+	payload, err := webhookPayload(cmd, cmd.PayloadTemplate, false, i, map[string]interface{}{"subject": "Hi"}, time.Now())
+	if err != nil {
+		t.Fatalf("Failed to build payload: %v", err)
+	}
+
+	data := payload.(config.PayloadData)
+	if data.GuildID != "" || data.User.ID != "123" || data.User.DisplayName != "alice" {
+		t.Errorf("Expected DM user context, got %+v", data)
+	}
+	if data.User.Roles == nil {
+		t.Error("Expected roles to be an empty list outside guilds, got nil")
+	}
+}
+
+func TestSubmitWebhook_FanOut(t *testing.T) {
+	// Both endpoints wait for each other, so the test only finishes when
+	// deliveries run at the same time
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	handler := func(status int, body *string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			data, _ := io.ReadAll(r.Body)
+			*body = string(data)
+			arrived.Done()
+			arrived.Wait()
+			w.WriteHeader(status)
+		}
+	}
+
+	var ticketsBody, analyticsBody string
+	tickets := httptest.NewServer(handler(http.StatusOK, &ticketsBody))
+	defer tickets.Close()
+	analytics := httptest.NewServer(handler(http.StatusBadRequest, &analyticsBody))
+	defer analytics.Close()
+
+	optional := false
+	cmd := &config.CommandSpec{
+		Name: "feedback",
+		Type: "modal",
+		Webhooks: []config.WebhookTarget{
+			{Name: "tickets", Webhook: config.WebhookSpec{URL: tickets.URL}},
+			{
+				Name:            "analytics",
+				Webhook:         config.WebhookSpec{URL: analytics.URL},
+				Required:        &optional,
+				PayloadTemplate: &config.PayloadTemplate{Text: `{"event": "{{ .Command }}"}`},
+			},
+		},
+	}
+	bot := &Bot{Config: &config.Config{}, WebhookService: NewWebhookService()}
+
+	results := bot.submitWebhook(cmd, testInteraction(), map[string]interface{}{"message": "Hi"}, nil)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].err != nil || results[1].err == nil {
+		t.Errorf("Expected tickets to succeed and analytics to fail, got %v and %v", results[0].err, results[1].err)
+	}
+	if !strings.Contains(ticketsBody, `"message":"Hi"`) || analyticsBody != `{"event":"feedback"}` {
+		t.Errorf("Expected each destination to get its own payload, got %s and %s", ticketsBody, analyticsBody)
+	}
+	if submissionFailed(results) {
+		t.Error("Expected an optional failure not to fail the submission")
+	}
+
+	status := webhookStatuses(results, false)
+	for _, want := range []string{"some optional destinations failed", "✅ **tickets**", "❌ **analytics** (optional)"} {
+		if !strings.Contains(status, want) {
+			t.Errorf("Expected status to contain '%s', got: %s", want, status)
+		}
+	}
+
+	results[0].err = errors.New("webhook returned status 500")
+	if !submissionFailed(results) || !strings.Contains(webhookStatuses(results, false), "Submission failed") {
+		t.Error("Expected a required failure to fail the submission")
+	}
+}
@@ -0,0 +1,110 @@
+package discord
+
+import (
+	"log"
+	"os"
+	"reflect"
+	"time"
+
+	"yambot/pkg/config"
+)
+
+// configPollInterval is how often the config file is checked for changes.
+const configPollInterval = 2 * time.Second
+
+// watchConfig polls the config file and reloads it whenever its size or
+// modification time changes. Polling is used instead of inotify so that
+// bind-mounted and symlink-swapped files (Docker, Kubernetes) are picked up.
+func (b *Bot) watchConfig(done <-chan struct{}) {
+	last, err := os.Stat(b.ConfigPath)
+	if err != nil {
+		log.Printf("Cannot watch config file %s: %v", b.ConfigPath, err)
+	}
+
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(b.ConfigPath)
+			if err != nil {
+				continue
+			}
+			if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+				continue
+			}
+			last = info
+			log.Printf("Config file %s changed, reloading...", b.ConfigPath)
+			b.reloadConfig()
+		}
+	}
+}
+
+// reloadConfig loads and validates the config file, swaps it in and syncs
+// the registered commands when any of them changed. An invalid file is
+// rejected and the bot keeps running on the last good config.
+func (b *Bot) reloadConfig() {
+	b.reloadMu.Lock()
+	defer b.reloadMu.Unlock()
+
+	newConfig, err := config.LoadConfig(b.ConfigPath)
+	if err != nil {
+		log.Printf("Config reload rejected, keeping last good config: %v", err)
+		return
+	}
+
+	oldConfig := b.currentConfig()
+	if newConfig.GetDiscordToken() != oldConfig.GetDiscordToken() {
+		log.Printf("Warning: bot.discord.token changed; the new token is only used after a restart")
+	}
+	if newConfig.Bot.Outbox != oldConfig.Bot.Outbox {
+		log.Printf("Warning: bot.outbox changed; the new outbox settings are only used after a restart")
+	}
+
+	added, changed, removed := diffCommands(oldConfig.GetCommands(), newConfig.GetCommands())
+
+	b.configMu.Lock()
+	b.Config = newConfig
+	b.configMu.Unlock()
+
+	guildsChanged := !reflect.DeepEqual(oldConfig.Bot.Discord.Guilds, newConfig.Bot.Discord.Guilds)
+	if len(added)+len(changed)+len(removed) > 0 || guildsChanged {
+		if err := b.syncCommands(newConfig, nil); err != nil {
+			log.Printf("Failed to sync commands after reload: %v", err)
+		}
+	}
+
+	log.Printf("Config reloaded: %d commands (%d added, %d changed, %d removed)",
+		len(newConfig.GetCommands()), len(added), len(changed), len(removed))
+}
+
+// diffCommands compares two command lists by name.
+func diffCommands(oldCommands, newCommands []config.CommandSpec) (added, changed, removed []config.CommandSpec) {
+	oldByName := make(map[string]config.CommandSpec, len(oldCommands))
+	for _, cmd := range oldCommands {
+		oldByName[cmd.Name] = cmd
+	}
+
+	newNames := make(map[string]bool, len(newCommands))
+	for _, cmd := range newCommands {
+		newNames[cmd.Name] = true
+		oldCmd, exists := oldByName[cmd.Name]
+		switch {
+		case !exists:
+			added = append(added, cmd)
+		case !oldCmd.SameSettings(cmd):
+			changed = append(changed, cmd)
+		}
+	}
+
+	for _, cmd := range oldCommands {
+		if !newNames[cmd.Name] {
+			removed = append(removed, cmd)
+		}
+	}
+
+	return added, changed, removed
+}
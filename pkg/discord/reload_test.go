@@ -0,0 +1,57 @@
+package discord
+
+import (
+	"testing"
+
+	"yambot/pkg/config"
+)
+
+func TestDiffCommands(t *testing.T) {
+	oldCommands := []config.CommandSpec{
+		{Name: "keep", Type: "slash"},
+		{Name: "change", Type: "slash", Webhook: config.WebhookSpec{URL: "https://example.com/old"}},
+		{Name: "remove", Type: "modal"},
+	}
+	newCommands := []config.CommandSpec{
+		{Name: "keep", Type: "slash"},
+		{Name: "change", Type: "slash", Webhook: config.WebhookSpec{URL: "https://example.com/new"}},
+		{Name: "add", Type: "modal"},
+	}
+
+	added, changed, removed := diffCommands(oldCommands, newCommands)
+
+	if len(added) != 1 || added[0].Name != "add" {
+		t.Errorf("Expected 'add' to be added, got %v", added)
+	}
+	if len(changed) != 1 || changed[0].Name != "change" {
+		t.Errorf("Expected 'change' to be changed, got %v", changed)
+	}
+	if len(removed) != 1 || removed[0].Name != "remove" {
+		t.Errorf("Expected 'remove' to be removed, got %v", removed)
+	}
+}
+
+func TestDiffCommands_ParsedTemplates(t *testing.T) {
+	load := func() []config.CommandSpec {
+		cfg := &config.Config{
+			Bot: config.BotConfig{Discord: config.DiscordConfig{Token: "TEST_TOKEN"}},
+			Commands: []config.CommandSpec{{
+				Name:            "ticket",
+				Type:            "modal",
+				Webhook:         config.WebhookSpec{URL: "https://example.com/tickets"},
+				PayloadTemplate: &config.PayloadTemplate{Text: `{"title": {{ json .Fields.title }}}`},
+				Response:        &config.ResponseSpec{Success: "Thanks {{ .User.DisplayName }}"},
+				Fields:          []config.FieldSpec{{Name: "title", Type: "text"}},
+			}},
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("Expected valid config, got: %v", err)
+		}
+		return cfg.Commands
+	}
+
+	added, changed, removed := diffCommands(load(), load())
+	if len(added)+len(changed)+len(removed) > 0 {
+		t.Errorf("Expected no changes, got %d added, %d changed and %d removed", len(added), len(changed), len(removed))
+	}
+}
@@ -0,0 +1,164 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxReplyBytes caps how much of a webhook response is read.
+const maxReplyBytes = 64 << 10
+
+// Discord's limits for a single message.
+const (
+	maxMessageContent    = 2000
+	maxMessageEmbeds     = 10
+	maxMessageComponents = 5
+)
+
+// webhookReply is the JSON a webhook can return to shape the reply to the
+// user, replacing the default confirmation message.
+type webhookReply struct {
+	Content    string                       `json:"content"`
+	Embeds     []*discordgo.MessageEmbed    `json:"embeds"`
+	Components []discordgo.MessageComponent `json:"components"`
+	// Ephemeral shows the reply to the submitting user only.
+	Ephemeral bool `json:"ephemeral"`
+	// Error is shown to the user instead of a reply and fails the submission.
+	Error string `json:"error"`
+}
+
+// UnmarshalJSON decodes components, which discordgo cannot unmarshal into the
+// MessageComponent interface by itself.
+func (r *webhookReply) UnmarshalJSON(data []byte) error {
+	type plain webhookReply
+	var raw struct {
+		plain
+		Components []json.RawMessage `json:"components"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*r = webhookReply(raw.plain)
+	r.Components = nil
+	for _, data := range raw.Components {
+		component, err := discordgo.MessageComponentFromJSON(data)
+		if err != nil {
+			return err
+		}
+		r.Components = append(r.Components, component)
+	}
+	return nil
+}
+
+// replyKeys are the keys that make a JSON response a reply.
+var replyKeys = []string{"content", "embeds", "components", "ephemeral", "error"}
+
+// parseWebhookReply parses the body of a webhook response. Empty bodies,
+// bodies that are not JSON objects and objects without any reply key are not
+// replies, and give nil; the default message is shown for them.
+func parseWebhookReply(body []byte) *webhookReply {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '{' {
+		return nil
+	}
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(body, &keys); err != nil {
+		return nil
+	}
+	isReply := false
+	for _, key := range replyKeys {
+		if _, ok := keys[key]; ok {
+			isReply = true
+			break
+		}
+	}
+	if !isReply {
+		return nil
+	}
+
+	var reply webhookReply
+	if err := json.Unmarshal(body, &reply); err != nil {
+		log.Printf("Ignoring invalid webhook reply: %v", err)
+		return nil
+	}
+
+	reply.Content = truncate(reply.Content, maxMessageContent)
+	if len(reply.Embeds) > maxMessageEmbeds {
+		reply.Embeds = reply.Embeds[:maxMessageEmbeds]
+	}
+	if len(reply.Components) > maxMessageComponents {
+		reply.Components = reply.Components[:maxMessageComponents]
+	}
+	return &reply
+}
+
+// replyFrom picks the reply to show for a submission: the first one returned
+// by a destination, in configuration order. A reply is only shown when the
+// submission succeeded, except for errors returned by required destinations,
+// which are always shown.
+func replyFrom(results []deliveryResult) *webhookReply {
+	failed := submissionFailed(results)
+	for _, result := range results {
+		reply := result.reply
+		if reply == nil {
+			continue
+		}
+		if reply.Error != "" {
+			if result.target.IsRequired() {
+				return reply
+			}
+			continue
+		}
+		if !failed {
+			return reply
+		}
+	}
+	return nil
+}
+
+// sendReply replaces the deferred response with a webhook's reply. A deferred
+// response keeps the visibility it was deferred with, so when it is public,
+// ephemeral replies and errors are sent as an ephemeral followup instead.
+// Replies to an ephemeral response stay ephemeral.
+func (b *Bot) sendReply(s *discordgo.Session, i *discordgo.InteractionCreate, reply *webhookReply, ephemeral bool) {
+	if reply.Error != "" {
+		b.failDeferred(s, i, "❌ "+truncate(reply.Error, maxMessageContent-2))
+		return
+	}
+
+	if reply.Ephemeral && !ephemeral {
+		if err := s.InteractionResponseDelete(i.Interaction); err != nil {
+			log.Printf("Error deleting deferred response: %v", err)
+		}
+
+		_, err := s.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
+			Content:         reply.Content,
+			Embeds:          reply.Embeds,
+			Components:      reply.Components,
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+		if err != nil {
+			log.Printf("Error sending webhook reply: %v", err)
+		}
+		return
+	}
+
+	edit := &discordgo.WebhookEdit{
+		Content:         &reply.Content,
+		Embeds:          &reply.Embeds,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+	if reply.Components != nil {
+		edit.Components = &reply.Components
+	}
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, edit); err != nil {
+		log.Printf("Error sending webhook reply: %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package discord
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseWebhookReply(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		wantReply     bool
+		wantContent   string
+		wantEphemeral bool
+		wantError     string
+	}{
+		{name: "empty body", body: ""},
+		{name: "plain text", body: "OK"},
+		{name: "JSON array", body: `[1, 2]`},
+		{name: "object without reply keys", body: `{"status": "created"}`},
+		{name: "invalid JSON", body: `{"content": `},
+		{
+			name:        "content",
+			body:        `{"content": "Ticket #42 created", "id": 42}`,
+			wantReply:   true,
+			wantContent: "Ticket #42 created",
+		},
+		{
+			name:          "ephemeral",
+			body:          ` {"content": "Only you", "ephemeral": true}`,
+			wantReply:     true,
+			wantContent:   "Only you",
+			wantEphemeral: true,
+		},
+		{
+			name:      "error",
+			body:      `{"error": "Project is archived"}`,
+			wantReply: true,
+			wantError: "Project is archived",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply := parseWebhookReply([]byte(tt.body))
+			if !tt.wantReply {
+				if reply != nil {
+					t.Errorf("Expected no reply, got %+v", reply)
+				}
+				return
+			}
+
+			if reply == nil {
+				t.Fatal("Expected a reply, got nil")
+			}
+			if reply.Content != tt.wantContent {
+				t.Errorf("Expected content '%s', got '%s'", tt.wantContent, reply.Content)
+			}
+			if reply.Ephemeral != tt.wantEphemeral {
+				t.Errorf("Expected ephemeral %v, got %v", tt.wantEphemeral, reply.Ephemeral)
+			}
+			if reply.Error != tt.wantError {
+				t.Errorf("Expected error '%s', got '%s'", tt.wantError, reply.Error)
+			}
+		})
+	}
+}
+
+func TestParseWebhookReply_EmbedsAndComponents(t *testing.T) {
+	body := `{
+		"embeds": [{"title": "Ticket #42", "color": 3066993}],
+		"components": [{"type": 1, "components": [{"type": 2, "style": 5, "label": "Open", "url": "https://tickets.example.com/42"}]}]
+	}`
+
+	reply := parseWebhookReply([]byte(body))
+	if reply == nil {
+		t.Fatal("Expected a reply, got nil")
+	}
+
+	if len(reply.Embeds) != 1 || reply.Embeds[0].Title != "Ticket #42" {
+		t.Fatalf("Expected one embed titled 'Ticket #42', got %+v", reply.Embeds)
+	}
+	if len(reply.Components) != 1 {
+		t.Fatalf("Expected one component row, got %d", len(reply.Components))
+	}
+	row, ok := reply.Components[0].(*discordgo.ActionsRow)
+	if !ok {
+		t.Fatalf("Expected an action row, got %T", reply.Components[0])
+	}
+	button, ok := row.Components[0].(*discordgo.Button)
+	if !ok || button.URL != "https://tickets.example.com/42" {
+		t.Errorf("Expected a link button, got %#v", row.Components[0])
+	}
+}
+
+func TestParseWebhookReply_Limits(t *testing.T) {
+	body := `{"content": "` + strings.Repeat("a", 2500) + `", "embeds": [` + strings.TrimSuffix(strings.Repeat(`{"title": "x"},`, 12), ",") + `]}`
+
+	reply := parseWebhookReply([]byte(body))
+	if reply == nil {
+		t.Fatal("Expected a reply, got nil")
+	}
+
+	if length := len([]rune(reply.Content)); length != maxMessageContent {
+		t.Errorf("Expected content truncated to %d characters, got %d", maxMessageContent, length)
+	}
+	if len(reply.Embeds) != maxMessageEmbeds {
+		t.Errorf("Expected %d embeds, got %d", maxMessageEmbeds, len(reply.Embeds))
+	}
+}
+
+func TestReplyFrom(t *testing.T) {
+	optional := false
+	required := config.WebhookTarget{Name: "tickets"}
+	extra := config.WebhookTarget{Name: "analytics", Required: &optional}
+	content := &webhookReply{Content: "Ticket #42 created"}
+	failure := &webhookReply{Error: "Project is archived"}
+
+	tests := []struct {
+		name    string
+		results []deliveryResult
+		want    *webhookReply
+	}{
+		{
+			name:    "no reply",
+			results: []deliveryResult{{target: required, attempts: 1}},
+		},
+		{
+			name:    "reply",
+			results: []deliveryResult{{target: required, attempts: 1, reply: content}},
+			want:    content,
+		},
+		{
+			name: "required destination failed",
+			results: []deliveryResult{
+				{target: required, attempts: 1, err: errors.New("webhook returned status 500")},
+				{target: extra, attempts: 1, reply: content},
+			},
+		},
+		{
+			name: "error from required destination",
+			results: []deliveryResult{
+				{target: required, attempts: 1, reply: failure, err: errors.New(failure.Error)},
+			},
+			want: failure,
+		},
+		{
+			name: "error from optional destination",
+			results: []deliveryResult{
+				{target: extra, attempts: 1, reply: failure, err: errors.New(failure.Error)},
+				{target: required, attempts: 1, reply: content},
+			},
+			want: content,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replyFrom(tt.results); got != tt.want {
+				t.Errorf("Expected reply %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPostWebhook_Reply(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"content": "Ticket #42 created"}`))
+	}))
+	defer server.Close()
+
+	reply, _, _, err := NewWebhookService().postWebhook(config.WebhookSpec{URL: server.URL}, []byte(`{}`), jsonContentType, config.DefaultRetryPolicy, "")
+	if err != nil {
+		t.Fatalf("Expected delivery to succeed, got %v", err)
+	}
+	if string(reply) != `{"content": "Ticket #42 created"}` {
+		t.Errorf("Expected the response body, got '%s'", reply)
+	}
+}
+
+func TestPostWebhook_ErrorReply(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"error": "Project is archived"}`))
+	}))
+	defer server.Close()
+
+	_, _, _, err := NewWebhookService().postWebhook(config.WebhookSpec{URL: server.URL}, []byte(`{}`), jsonContentType, config.DefaultRetryPolicy, "")
+	if err == nil {
+		t.Fatal("Expected delivery to fail")
+	}
+	if err.Error() != "webhook returned status 422: Project is archived" {
+		t.Errorf("Expected the returned error in the message, got '%v'", err)
+	}
+}
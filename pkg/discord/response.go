@@ -0,0 +1,123 @@
+package discord
+
+import (
+	"errors"
+	"log"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// customResponse renders the command's success or failure message for a
+// submission. It reports false when the command has no message for the
+// outcome, or it fails to render, so the default message is shown.
+func customResponse(cmd *config.CommandSpec, i *discordgo.InteractionCreate, display map[string]string, results []deliveryResult) (string, bool) {
+	if cmd.Response == nil {
+		return "", false
+	}
+
+	name, text := "success", cmd.Response.Success
+	if submissionFailed(results) {
+		name, text = "failure", cmd.Response.Failure
+	}
+	if text == "" {
+		return "", false
+	}
+
+	data := responseData(cmd, i, display)
+	data.Delivery = deliveryData(results)
+
+	return renderResponse(cmd, name, data)
+}
+
+// validationResponse is the reply to input that failed validation: the
+// command's validation message if it has one, or the default message.
+func validationResponse(cmd *config.CommandSpec, i *discordgo.InteractionCreate, display map[string]string, err error) string {
+	if cmd.Response != nil && cmd.Response.Validation != "" {
+		data := responseData(cmd, i, display)
+		data.Error = err.Error()
+		if response, ok := renderResponse(cmd, "validation", data); ok {
+			return response
+		}
+	}
+	return validationErrorMessage(err)
+}
+
+func renderResponse(cmd *config.CommandSpec, name string, data config.ResponseData) (string, bool) {
+	response, err := cmd.Response.Render(name, data)
+	if err != nil {
+		log.Printf("Error rendering %s response for %s: %v", name, cmd.Name, err)
+		return "", false
+	}
+	return truncate(response, maxMessageContent), true
+}
+
+// responseData describes a submission of cmd made through interaction i.
+func responseData(cmd *config.CommandSpec, i *discordgo.InteractionCreate, display map[string]string) config.ResponseData {
+	data := config.ResponseData{
+		Command:   cmd.Name,
+		User:      payloadUser(i),
+		GuildID:   i.GuildID,
+		ChannelID: i.ChannelID,
+		Fields:    make(map[string]string, len(cmd.Fields)),
+	}
+
+	// Fields left empty are present, so templates can test them with if
+	for _, field := range cmd.Fields {
+		data.Fields[field.Name] = ""
+	}
+	for name, value := range display {
+		data.Fields[name] = value
+	}
+	return data
+}
+
+// deliveryData summarises the outcome of a submission for response
+// templates.
+func deliveryData(results []deliveryResult) config.DeliveryData {
+	var delivery config.DeliveryData
+	if len(results) == 0 {
+		return delivery
+	}
+
+	failed, queued := false, false
+	for _, result := range results {
+		destination := config.DestinationData{
+			Name:     result.target.Name,
+			Required: result.target.IsRequired(),
+			Status:   "sent",
+			Attempts: result.attempts,
+		}
+
+		var queuedErr *queuedError
+		switch {
+		case errors.As(result.err, &queuedErr):
+			destination.Status = "queued"
+			destination.Error = queuedErr.Unwrap().Error()
+			queued = true
+		case result.err != nil:
+			destination.Status = "failed"
+			destination.Error = result.err.Error()
+			failed = true
+		}
+
+		if destination.Error != "" && delivery.Error == "" {
+			delivery.Error = destination.Error
+		}
+		delivery.Attempts += result.attempts
+		delivery.Destinations = append(delivery.Destinations, destination)
+	}
+
+	switch {
+	case submissionFailed(results):
+		delivery.Status = "failed"
+	case failed:
+		delivery.Status = "partial"
+	case queued:
+		delivery.Status = "queued"
+	default:
+		delivery.Status = "sent"
+	}
+	return delivery
+}
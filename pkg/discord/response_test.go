@@ -0,0 +1,174 @@
+package discord
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestCustomResponse(t *testing.T) {
+	cmd := &config.CommandSpec{
+		Name:    "ticket",
+		Webhook: config.WebhookSpec{URL: "https://example.com/tickets"},
+		Response: &config.ResponseSpec{
+			Success: "Thanks {{ .User.DisplayName }}, {{ .Fields.title }} is {{ .Delivery.Status }}.",
+			Failure: "Sorry: {{ .Delivery.Error }}",
+		},
+		Fields: []config.FieldSpec{{Name: "title", Type: "text"}},
+	}
+	display := map[string]string{"title": "Printer on fire"}
+	target := cmd.Targets()[0]
+
+	tests := []struct {
+		name    string
+		results []deliveryResult
+		want    string
+	}{
+		{
+			name:    "delivered",
+			results: []deliveryResult{{target: target, attempts: 1}},
+			want:    "Thanks Ally, Printer on fire is sent.",
+		},
+		{
+			name:    "queued",
+			results: []deliveryResult{{target: target, attempts: 3, err: &queuedError{err: errors.New("webhook returned status 503")}}},
+			want:    "Thanks Ally, Printer on fire is queued.",
+		},
+		{
+			name:    "failed",
+			results: []deliveryResult{{target: target, attempts: 1, err: errors.New("webhook returned status 500")}},
+			want:    "Sorry: webhook returned status 500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := customResponse(cmd, testInteraction(), display, tt.results)
+			if !ok {
+				t.Fatal("Expected a custom response")
+			}
+			if got != tt.want {
+				t.Errorf("Expected '%s', got '%s'", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCustomResponse_Defaults(t *testing.T) {
+	cmd := &config.CommandSpec{
+		Name:     "ticket",
+		Webhook:  config.WebhookSpec{URL: "https://example.com/tickets"},
+		Response: &config.ResponseSpec{Success: "Thanks!"},
+	}
+	failed := []deliveryResult{{target: cmd.Targets()[0], attempts: 1, err: errors.New("webhook returned status 500")}}
+
+	if _, ok := customResponse(cmd, testInteraction(), nil, failed); ok {
+		t.Error("Expected the default message for a failure without a failure template")
+	}
+
+	cmd.Response.Success = "{{ .Fields.title.missing }}"
+	if _, ok := customResponse(cmd, testInteraction(), nil, nil); ok {
+		t.Error("Expected the default message for a template that fails to render")
+	}
+
+	cmd.Response = nil
+	if _, ok := customResponse(cmd, testInteraction(), nil, nil); ok {
+		t.Error("Expected the default message without a response block")
+	}
+}
+
+func TestValidationResponse(t *testing.T) {
+	err := errors.New("• **Email** must be a valid email address")
+	cmd := &config.CommandSpec{Name: "signup"}
+
+	if got := validationResponse(cmd, testInteraction(), nil, err); got != validationErrorMessage(err) {
+		t.Errorf("Expected the default validation message, got '%s'", got)
+	}
+
+	cmd.Response = &config.ResponseSpec{Validation: "{{ .User.DisplayName }}, please fix this:\n{{ .Error }}"}
+	want := "Ally, please fix this:\n• **Email** must be a valid email address"
+	if got := validationResponse(cmd, testInteraction(), nil, err); got != want {
+		t.Errorf("Expected '%s', got '%s'", want, got)
+	}
+}
+
+func TestCreateFormResponse_Hidden(t *testing.T) {
+	bot := &Bot{}
+	cmd := &config.CommandSpec{
+		Name:     "cost",
+		Webhook:  config.WebhookSpec{URL: "https://example.com/webhook"},
+		Response: &config.ResponseSpec{HideFields: true, HideEndpoint: true},
+		Fields:   []config.FieldSpec{{Name: "title", Type: "text", Required: true}},
+	}
+
+	response := bot.createFormResponse(cmd, map[string]string{"title": "Test Title"}, []deliveryResult{{target: cmd.Targets()[0], attempts: 1}})
+
+	if strings.Contains(response, "Test Title") || strings.Contains(response, "Summary") {
+		t.Errorf("Expected no field values, got: %s", response)
+	}
+	if strings.Contains(response, "example.com") || strings.Contains(response, "Endpoint") {
+		t.Errorf("Expected no endpoint, got: %s", response)
+	}
+	if !strings.Contains(response, "Data sent successfully") {
+		t.Errorf("Expected the webhook status, got: %s", response)
+	}
+}
+
+func TestSlashCommandResponse_Hidden(t *testing.T) {
+	cmd := &config.CommandSpec{
+		Name: "report",
+		Webhooks: []config.WebhookTarget{
+			{Name: "tickets", Webhook: config.WebhookSpec{URL: "https://tickets.example.com"}},
+			{Webhook: config.WebhookSpec{URL: "https://analytics.example.com"}},
+		},
+		Response: &config.ResponseSpec{HideFields: true, HideEndpoint: true},
+		Fields:   []config.FieldSpec{{Name: "title", Type: "text"}},
+	}
+	options := []*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "title", Type: discordgo.ApplicationCommandOptionString, Value: "Printer on fire"},
+	}
+	targets := cmd.Targets()
+	results := []deliveryResult{{target: targets[0], attempts: 1}, {target: targets[1], attempts: 1}}
+
+	response := slashCommandResponse(cmd, options, map[string]string{"title": "Printer on fire"}, results)
+
+	if strings.Contains(response, "Printer on fire") {
+		t.Errorf("Expected no field values, got: %s", response)
+	}
+	if strings.Contains(response, "example.com") {
+		t.Errorf("Expected no endpoints, got: %s", response)
+	}
+	if !strings.Contains(response, "**tickets**") || !strings.Contains(response, "**Destination 2**") {
+		t.Errorf("Expected named and numbered destinations, got: %s", response)
+	}
+}
+
+func TestDeliveryData(t *testing.T) {
+	optional := false
+	results := []deliveryResult{
+		{target: config.WebhookTarget{Name: "tickets"}, attempts: 2},
+		{target: config.WebhookTarget{Name: "analytics", Required: &optional}, attempts: 1, err: errors.New("webhook returned status 500")},
+	}
+
+	delivery := deliveryData(results)
+	if delivery.Status != "partial" {
+		t.Errorf("Expected status 'partial', got '%s'", delivery.Status)
+	}
+	if delivery.Attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", delivery.Attempts)
+	}
+	if delivery.Error != "webhook returned status 500" {
+		t.Errorf("Expected the optional destination's error, got '%s'", delivery.Error)
+	}
+	if len(delivery.Destinations) != 2 || delivery.Destinations[1].Status != "failed" || delivery.Destinations[1].Required {
+		t.Errorf("Unexpected destinations %+v", delivery.Destinations)
+	}
+
+	if none := deliveryData(nil); none.Status != "" {
+		t.Errorf("Expected no status without a webhook, got '%s'", none.Status)
+	}
+}
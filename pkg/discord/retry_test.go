@@ -0,0 +1,133 @@
+package discord
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"yambot/pkg/config"
+)
+
+func testRetryPolicy(maxAttempts int) config.RetryPolicy {
+	return config.RetryPolicy{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+		RetryOn:     []int{429, 503},
+	}
+}
+
+// retryBot returns a bot delivering to url with the given policy, without an
+// outbox, and the command to deliver.
+func retryBot(url string, policy config.RetryPolicy) (*Bot, *config.CommandSpec) {
+	bot := &Bot{Config: &config.Config{}, WebhookService: NewWebhookService()}
+	cmd := &config.CommandSpec{Name: "report", Webhook: config.WebhookSpec{URL: url}, Retry: &policy}
+	return bot, cmd
+}
+
+func TestDeliverWebhook_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		retryAfter   string
+		maxAttempts  int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"success on first try", []int{200}, "", 3, 1, false},
+		{"retryable status then success", []int{503, 503, 200}, "", 3, 3, false},
+		{"gives up after max attempts", []int{503, 503, 503, 503}, "", 3, 3, true},
+		{"status not retryable", []int{400, 200}, "", 3, 1, true},
+		{"single attempt by default", []int{503, 200}, "", 1, 1, true},
+		{"honours short Retry-After", []int{429, 200}, "0", 3, 2, false},
+		{"Retry-After beyond max delay", []int{429, 200}, "60", 3, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.statuses[min(requests, len(tt.statuses)-1)]
+				requests++
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+
+			bot, cmd := retryBot(server.URL, testRetryPolicy(tt.maxAttempts))
+			attempts, _, err := bot.deliverWebhook(cmd, cmd.Targets()[0], map[string]string{"a": "b"})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if attempts != tt.wantAttempts || requests != tt.wantAttempts {
+				t.Errorf("Expected %d attempts, got %d (%d requests)", tt.wantAttempts, attempts, requests)
+			}
+		})
+	}
+}
+
+func TestDeliverWebhook_RetriesNetworkErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	bot, cmd := retryBot(url, testRetryPolicy(2))
+	attempts, _, err := bot.deliverWebhook(cmd, cmd.Targets()[0], nil)
+	if err == nil {
+		t.Fatal("Expected delivery to a closed server to fail")
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	policy := config.RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 200 * time.Millisecond, 400 * time.Millisecond},
+		{10, 500 * time.Millisecond, time.Second},
+	}
+
+	for _, tt := range tests {
+		for range 20 {
+			delay := backoffDelay(policy, tt.attempt)
+			if delay < tt.min || delay > tt.max {
+				t.Errorf("Attempt %d: expected delay between %s and %s, got %s", tt.attempt, tt.min, tt.max, delay)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{"Mon, 01 Jan 2024 12:00:30 GMT", 30 * time.Second, true},
+		{"Mon, 01 Jan 2024 11:00:00 GMT", 0, true},
+		{"soon", 0, false},
+		{"-1", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.header, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %s, %v; expected %s, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
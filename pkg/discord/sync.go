@@ -0,0 +1,135 @@
+package discord
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// commandDiff lists, by name, what a sync has to change in Discord.
+type commandDiff struct {
+	create []string
+	update []string
+	delete []string
+}
+
+func (d commandDiff) empty() bool {
+	return len(d.create) == 0 && len(d.update) == 0 && len(d.delete) == 0
+}
+
+// diffApplicationCommands compares the commands registered in Discord with
+// the desired ones. Only the settings yambot controls are compared, so fields
+// Discord fills in itself (IDs, versions, localizations) never cause updates.
+func diffApplicationCommands(registered, desired []*discordgo.ApplicationCommand) commandDiff {
+	var diff commandDiff
+
+	registeredByName := make(map[string]*discordgo.ApplicationCommand, len(registered))
+	for _, command := range registered {
+		registeredByName[command.Name] = command
+	}
+
+	desiredNames := make(map[string]bool, len(desired))
+	for _, command := range desired {
+		desiredNames[command.Name] = true
+		existing, exists := registeredByName[command.Name]
+		switch {
+		case !exists:
+			diff.create = append(diff.create, command.Name)
+		case !commandsEqual(existing, command):
+			diff.update = append(diff.update, command.Name)
+		}
+	}
+
+	for _, command := range registered {
+		if !desiredNames[command.Name] {
+			diff.delete = append(diff.delete, command.Name)
+		}
+	}
+
+	return diff
+}
+
+func commandsEqual(a, b *discordgo.ApplicationCommand) bool {
+	return a.Name == b.Name &&
+		a.Description == b.Description &&
+		commandType(a) == commandType(b) &&
+		permissionsEqual(a.DefaultMemberPermissions, b.DefaultMemberPermissions) &&
+		optionsEqual(a.Options, b.Options)
+}
+
+func commandType(c *discordgo.ApplicationCommand) discordgo.ApplicationCommandType {
+	if c.Type == 0 {
+		return discordgo.ChatApplicationCommand
+	}
+	return c.Type
+}
+
+func permissionsEqual(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func optionsEqual(a, b []*discordgo.ApplicationCommandOption) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !optionEqual(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func optionEqual(a, b *discordgo.ApplicationCommandOption) bool {
+	if a.Type != b.Type ||
+		a.Name != b.Name ||
+		a.Description != b.Description ||
+		a.Required != b.Required ||
+		a.Autocomplete != b.Autocomplete ||
+		a.MaxValue != b.MaxValue ||
+		a.MaxLength != b.MaxLength ||
+		!floatPtrEqual(a.MinValue, b.MinValue) ||
+		!intPtrEqual(a.MinLength, b.MinLength) {
+		return false
+	}
+
+	if len(a.ChannelTypes) != len(b.ChannelTypes) {
+		return false
+	}
+	for i := range a.ChannelTypes {
+		if a.ChannelTypes[i] != b.ChannelTypes[i] {
+			return false
+		}
+	}
+
+	if len(a.Choices) != len(b.Choices) {
+		return false
+	}
+	for i := range a.Choices {
+		// Choice values come back from Discord as decoded JSON, so compare
+		// their printed form rather than their Go types.
+		if a.Choices[i].Name != b.Choices[i].Name ||
+			fmt.Sprint(a.Choices[i].Value) != fmt.Sprint(b.Choices[i].Value) {
+			return false
+		}
+	}
+
+	return optionsEqual(a.Options, b.Options)
+}
+
+func floatPtrEqual(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func intPtrEqual(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
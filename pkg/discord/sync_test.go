@@ -0,0 +1,71 @@
+package discord
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDiffApplicationCommands(t *testing.T) {
+	registered := []*discordgo.ApplicationCommand{
+		{
+			ID:          "1",
+			Version:     "100",
+			Type:        discordgo.ChatApplicationCommand,
+			Name:        "keep",
+			Description: "Execute keep command",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "priority",
+					Description: "Enter priority",
+					Required:    true,
+					Choices:     []*discordgo.ApplicationCommandOptionChoice{{Name: "High", Value: "High"}},
+				},
+			},
+		},
+		{ID: "2", Name: "change", Description: "Execute change command"},
+		{ID: "3", Name: "stale", Description: "Execute stale command"},
+	}
+
+	desired := []*discordgo.ApplicationCommand{
+		{
+			Name:        "keep",
+			Description: "Execute keep command",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "priority",
+					Description: "Enter priority",
+					Required:    true,
+					Choices:     []*discordgo.ApplicationCommandOptionChoice{{Name: "High", Value: "High"}},
+				},
+			},
+		},
+		{Name: "change", Description: "Open change form"},
+		{Name: "new", Description: "Execute new command"},
+	}
+
+	diff := diffApplicationCommands(registered, desired)
+
+	if len(diff.create) != 1 || diff.create[0] != "new" {
+		t.Errorf("Expected 'new' to be created, got %v", diff.create)
+	}
+	if len(diff.update) != 1 || diff.update[0] != "change" {
+		t.Errorf("Expected 'change' to be updated, got %v", diff.update)
+	}
+	if len(diff.delete) != 1 || diff.delete[0] != "stale" {
+		t.Errorf("Expected 'stale' to be deleted, got %v", diff.delete)
+	}
+}
+
+func TestDiffApplicationCommands_InSync(t *testing.T) {
+	commands := []*discordgo.ApplicationCommand{
+		{Name: "report", Description: "Execute report command"},
+	}
+
+	diff := diffApplicationCommands(commands, commands)
+	if !diff.empty() {
+		t.Errorf("Expected no changes, got %+v", diff)
+	}
+}
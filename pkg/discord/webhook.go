@@ -2,6 +2,7 @@ package discord
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,9 +10,15 @@ import (
 	"net/http"
 	"time"
 
+	"yambot/pkg/config"
+	"yambot/pkg/signature"
+
 	"github.com/bwmarrin/discordgo"
 )
 
+// jsonContentType is the content type of JSON webhook bodies.
+const jsonContentType = "application/json"
+
 // WebhookService handles webhook operations
 type WebhookService struct{}
 
@@ -20,79 +27,254 @@ func NewWebhookService() *WebhookService {
 	return &WebhookService{}
 }
 
-// SendWebhook sends form data to a webhook URL
-func (ws *WebhookService) SendWebhook(webhookURL string, formData map[string]string) error {
-	payload, err := json.Marshal(formData)
+// encodePayload returns the request body for a payload and its content type.
+// Payloads are sent as JSON unless they are already encoded.
+func encodePayload(payload interface{}) ([]byte, string, error) {
+	if encoded, ok := payload.(*encodedPayload); ok {
+		return encoded.body, encoded.contentType, nil
+	}
+
+	body, err := marshalPayload(payload)
+	return body, jsonContentType, err
+}
+
+func marshalPayload(payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
 	if err != nil {
 		log.Printf("Error marshaling form data for webhook: %v", err)
-		return fmt.Errorf("failed to prepare webhook data")
+		return nil, fmt.Errorf("failed to prepare webhook data")
+	}
+	return body, nil
+}
+
+// deliverBody posts an encoded payload, retrying as the policy allows and
+// signing each attempt with secret when it is set. It returns the number of
+// attempts made, the response body once delivered and, on failure, whether a
+// later attempt might still succeed.
+func (ws *WebhookService) deliverBody(webhook config.WebhookSpec, body []byte, contentType string, policy config.RetryPolicy, secret string) (int, []byte, bool, error) {
+	webhookURL := webhook.URL
+	for attempt := 1; ; attempt++ {
+		reply, retryable, retryAfter, err := ws.postWebhook(webhook, body, contentType, policy, secret)
+		if err == nil {
+			log.Printf("Successfully sent webhook to %s (attempt %d)", webhookURL, attempt)
+			return attempt, reply, false, nil
+		}
+		if !retryable || attempt >= policy.MaxAttempts {
+			return attempt, nil, retryable, err
+		}
+
+		delay := backoffDelay(policy, attempt)
+		if retryAfter > 0 {
+			// Retrying before the server asked us to would fail again
+			if retryAfter > policy.MaxDelay {
+				log.Printf("Webhook %s asked to retry after %s, longer than the %s max delay; giving up", webhookURL, retryAfter, policy.MaxDelay)
+				return attempt, nil, true, err
+			}
+			delay = retryAfter
+		}
+
+		log.Printf("Webhook attempt %d/%d to %s failed (%v), retrying in %s", attempt, policy.MaxAttempts, webhookURL, err, delay)
+		time.Sleep(delay)
 	}
+}
 
+// postWebhook makes a single delivery attempt and returns the response body.
+// On failure it reports whether the attempt may be retried and how long the
+// server asked to wait. An "error" in a failed response's JSON body is added
+// to the returned error.
+func (ws *WebhookService) postWebhook(webhook config.WebhookSpec, body []byte, contentType string, policy config.RetryPolicy, secret string) ([]byte, bool, time.Duration, error) {
+	webhookURL := webhook.URL
 	client := &http.Client{
-		Timeout: 10 * time.Second,
+		Timeout: webhook.RequestTimeout(),
 	}
 
-	req, err := http.NewRequest("POST", webhookURL, bytes.NewBuffer(payload))
+	req, err := newWebhookRequest(context.Background(), webhook, webhookURL, "POST", body, contentType)
 	if err != nil {
 		log.Printf("Error creating webhook request: %v", err)
-		return fmt.Errorf("failed to create webhook request")
+		return nil, false, 0, fmt.Errorf("failed to create webhook request")
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		// Signed per attempt, so retries carry a fresh timestamp
+		signature.SetHeaders(req.Header, secret, time.Now(), body)
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Printf("Error sending webhook to %s: %v", webhookURL, err)
-		return fmt.Errorf("failed to send webhook")
+		return nil, true, 0, fmt.Errorf("failed to send webhook")
 	}
 	defer resp.Body.Close()
 
+	// The body is only needed for a reply, so a failure to read it does not
+	// fail the delivery
+	reply, err := io.ReadAll(io.LimitReader(resp.Body, maxReplyBytes))
+	if err != nil {
+		log.Printf("Error reading webhook response from %s: %v", webhookURL, err)
+		reply = nil
+	}
+
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		log.Printf("Webhook returned non-success status %d for URL %s", resp.StatusCode, webhookURL)
-		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+		retryAfter, _ := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		err := fmt.Errorf("webhook returned status %d", resp.StatusCode)
+		if parsed := parseWebhookReply(reply); parsed != nil && parsed.Error != "" {
+			err = fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, parsed.Error)
+		}
+		return nil, policy.Retryable(resp.StatusCode), retryAfter, err
+	}
+
+	return reply, false, 0, nil
+}
+
+// newWebhookRequest builds a request to requestURL with the webhook's method,
+// falling back to defaultMethod, and its headers and auth.
+func newWebhookRequest(ctx context.Context, webhook config.WebhookSpec, requestURL, defaultMethod string, body []byte, contentType string) (*http.Request, error) {
+	method := webhook.Method
+	if method == "" {
+		method = defaultMethod
 	}
 
-	log.Printf("Successfully sent webhook to %s", webhookURL)
-	return nil
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	for name, value := range webhook.Headers {
+		req.Header.Set(name, value)
+	}
+
+	if auth := webhook.Auth; auth != nil {
+		switch auth.Type {
+		case "bearer":
+			req.Header.Set("Authorization", "Bearer "+auth.Token)
+		case "basic":
+			req.SetBasicAuth(auth.Username, auth.Password)
+		case "header":
+			req.Header.Set(auth.Header, auth.Value)
+		}
+	}
+
+	return req, nil
 }
 
-// SendSlashCommandWebhook sends slash command data to a webhook URL
-func (ws *WebhookService) SendSlashCommandWebhook(webhookURL string, commandName string, options []*discordgo.ApplicationCommandInteractionDataOption, attachments map[string]*discordgo.MessageAttachment) error {
-	// Convert slash command options to map
-	formData := make(map[string]string)
-	formData["command"] = commandName
+// slashCommandPayload converts slash command options to a webhook payload.
+// Each value keeps its Discord type; users, roles, channels and attachments
+// are sent as their ID plus a "<field>_<kind>" entry with the resolved
+// details. Optional fields the user left out are sent as null. The command
+// name is added under "command" unless a field has that name.
+func slashCommandPayload(cmd *config.CommandSpec, options []*discordgo.ApplicationCommandInteractionDataOption, resolved *discordgo.ApplicationCommandInteractionDataResolved) map[string]interface{} {
+	payload := make(map[string]interface{})
+	if findFieldSpec(cmd, "command") == nil {
+		payload["command"] = cmd.Name
+	}
+	for _, field := range cmd.Fields {
+		payload[field.Name] = nil
+	}
 
-	// Safely handle nil attachments
-	if attachments == nil {
-		attachments = make(map[string]*discordgo.MessageAttachment)
+	// Safely handle nil resolved data
+	if resolved == nil {
+		resolved = &discordgo.ApplicationCommandInteractionDataResolved{}
 	}
 
 	for _, option := range options {
 		switch option.Type {
 		case discordgo.ApplicationCommandOptionString:
-			formData[option.Name] = option.StringValue()
+			payload[option.Name] = option.StringValue()
+		case discordgo.ApplicationCommandOptionInteger:
+			payload[option.Name] = option.IntValue()
+		case discordgo.ApplicationCommandOptionNumber:
+			payload[option.Name] = option.FloatValue()
+		case discordgo.ApplicationCommandOptionBoolean:
+			payload[option.Name] = option.BoolValue()
+		case discordgo.ApplicationCommandOptionUser:
+			id := option.Value.(string)
+			payload[option.Name] = id
+			addResolvedUser(payload, option.Name, id, resolved)
+		case discordgo.ApplicationCommandOptionRole:
+			id := option.Value.(string)
+			payload[option.Name] = id
+			addResolvedRole(payload, option.Name, id, resolved)
+		case discordgo.ApplicationCommandOptionMentionable:
+			id := option.Value.(string)
+			payload[option.Name] = id
+			addResolvedUser(payload, option.Name, id, resolved)
+			addResolvedRole(payload, option.Name, id, resolved)
+		case discordgo.ApplicationCommandOptionChannel:
+			id := option.Value.(string)
+			payload[option.Name] = id
+			if channel, exists := resolved.Channels[id]; exists {
+				payload[option.Name+"_channel"] = map[string]interface{}{
+					"id":   channel.ID,
+					"name": channel.Name,
+					"type": channel.Type,
+				}
+			}
 		case discordgo.ApplicationCommandOptionAttachment:
 			attachmentID := option.Value.(string)
-			if attachment, exists := attachments[attachmentID]; exists {
+			if attachment, exists := resolved.Attachments[attachmentID]; exists {
 				// For now, we'll include file info in the JSON payload
 				// In a more advanced implementation, you might want to send multipart/form-data
-				formData[option.Name] = fmt.Sprintf("File: %s (%s, %d bytes)", attachment.Filename, attachment.ContentType, attachment.Size)
-				formData[option.Name+"_url"] = attachment.URL
-				formData[option.Name+"_content_type"] = attachment.ContentType
-				formData[option.Name+"_size"] = fmt.Sprintf("%d", attachment.Size)
+				payload[option.Name] = fmt.Sprintf("File: %s (%s, %d bytes)", attachment.Filename, attachment.ContentType, attachment.Size)
+				payload[option.Name+"_url"] = attachment.URL
+				payload[option.Name+"_content_type"] = attachment.ContentType
+				payload[option.Name+"_size"] = attachment.Size
 			} else {
-				formData[option.Name] = attachmentID
+				payload[option.Name] = attachmentID
 			}
 		default:
-			formData[option.Name] = fmt.Sprintf("%v", option.Value)
+			payload[option.Name] = option.Value
 		}
 	}
 
-	return ws.SendWebhook(webhookURL, formData)
+	return payload
 }
 
-// downloadAttachment downloads a file from Discord URL
-func (ws *WebhookService) downloadAttachment(url string) ([]byte, error) {
+func addResolvedUser(payload map[string]interface{}, name, id string, resolved *discordgo.ApplicationCommandInteractionDataResolved) {
+	user, exists := resolved.Users[id]
+	if !exists {
+		return
+	}
+
+	details := map[string]interface{}{
+		"id":           user.ID,
+		"username":     user.Username,
+		"global_name":  user.GlobalName,
+		"bot":          user.Bot,
+		"display_name": user.DisplayName(),
+	}
+	if member, exists := resolved.Members[id]; exists {
+		details["nick"] = member.Nick
+		details["roles"] = member.Roles
+		if member.Nick != "" {
+			details["display_name"] = member.Nick
+		}
+	}
+	payload[name+"_user"] = details
+}
+
+func addResolvedRole(payload map[string]interface{}, name, id string, resolved *discordgo.ApplicationCommandInteractionDataResolved) {
+	role, exists := resolved.Roles[id]
+	if !exists {
+		return
+	}
+
+	payload[name+"_role"] = map[string]interface{}{
+		"id":    role.ID,
+		"name":  role.Name,
+		"color": role.Color,
+	}
+}
+
+// downloadAttachment downloads a file from Discord URL, failing for files
+// larger than maxSize bytes
+func (ws *WebhookService) downloadAttachment(url string, maxSize int64) ([]byte, error) {
 	client := &http.Client{
 		Timeout: 30 * time.Second,
 	}
@@ -112,10 +294,13 @@ func (ws *WebhookService) downloadAttachment(url string) ([]byte, error) {
 		return nil, fmt.Errorf("download returned status %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	if int64(len(body)) > maxSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxSize)
+	}
 
 	return body, nil
 }
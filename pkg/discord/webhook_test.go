@@ -0,0 +1,203 @@
+package discord
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"yambot/pkg/config"
+	"yambot/pkg/signature"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSlashCommandPayload_TypedValues(t *testing.T) {
+	cmd := &config.CommandSpec{
+		Name: "assign",
+		Fields: []config.FieldSpec{
+			{Name: "count", Type: "integer"},
+			{Name: "ratio", Type: "number"},
+			{Name: "urgent", Type: "boolean"},
+			{Name: "assignee", Type: "user"},
+			{Name: "team", Type: "role"},
+			{Name: "where", Type: "channel"},
+			{Name: "notes", Type: "text"},
+		},
+	}
+
+	// Option values arrive as decoded JSON, so numbers are float64
+	options := []*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "count", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(3)},
+		{Name: "ratio", Type: discordgo.ApplicationCommandOptionNumber, Value: 0.5},
+		{Name: "urgent", Type: discordgo.ApplicationCommandOptionBoolean, Value: true},
+		{Name: "assignee", Type: discordgo.ApplicationCommandOptionUser, Value: "200000000000000001"},
+		{Name: "team", Type: discordgo.ApplicationCommandOptionRole, Value: "100000000000000001"},
+		{Name: "where", Type: discordgo.ApplicationCommandOptionChannel, Value: "300000000000000001"},
+	}
+
+	resolved := &discordgo.ApplicationCommandInteractionDataResolved{
+		Users: map[string]*discordgo.User{
+			"200000000000000001": {ID: "200000000000000001", Username: "jane"},
+		},
+		Members: map[string]*discordgo.Member{
+			"200000000000000001": {Nick: "Jane D", Roles: []string{"100000000000000001"}},
+		},
+		Roles: map[string]*discordgo.Role{
+			"100000000000000001": {ID: "100000000000000001", Name: "ops"},
+		},
+		Channels: map[string]*discordgo.Channel{
+			"300000000000000001": {ID: "300000000000000001", Name: "general"},
+		},
+	}
+
+	payload := slashCommandPayload(cmd, options, resolved)
+
+	if payload["command"] != "assign" {
+		t.Errorf("Expected command 'assign', got %v", payload["command"])
+	}
+	if payload["count"] != int64(3) {
+		t.Errorf("Expected count int64(3), got %#v", payload["count"])
+	}
+	if payload["ratio"] != 0.5 {
+		t.Errorf("Expected ratio 0.5, got %#v", payload["ratio"])
+	}
+	if payload["urgent"] != true {
+		t.Errorf("Expected urgent true, got %#v", payload["urgent"])
+	}
+	if payload["assignee"] != "200000000000000001" {
+		t.Errorf("Expected assignee ID, got %#v", payload["assignee"])
+	}
+
+	user, ok := payload["assignee_user"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected resolved user details, got %#v", payload["assignee_user"])
+	}
+	if user["username"] != "jane" || user["display_name"] != "Jane D" {
+		t.Errorf("Expected username 'jane' and display name 'Jane D', got %v", user)
+	}
+
+	role, ok := payload["team_role"].(map[string]interface{})
+	if !ok || role["name"] != "ops" {
+		t.Errorf("Expected resolved role 'ops', got %#v", payload["team_role"])
+	}
+
+	channel, ok := payload["where_channel"].(map[string]interface{})
+	if !ok || channel["name"] != "general" {
+		t.Errorf("Expected resolved channel 'general', got %#v", payload["where_channel"])
+	}
+
+	if value, exists := payload["notes"]; !exists || value != nil {
+		t.Errorf("Expected missing optional field to be null, got %#v (exists=%v)", value, exists)
+	}
+}
+
+func TestSlashCommandPayload_NilResolved(t *testing.T) {
+	cmd := &config.CommandSpec{Name: "report", Fields: []config.FieldSpec{{Name: "file", Type: "attachment"}}}
+
+	options := []*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "file", Type: discordgo.ApplicationCommandOptionAttachment, Value: "123"},
+	}
+
+	payload := slashCommandPayload(cmd, options, nil)
+	if payload["file"] != "123" {
+		t.Errorf("Expected attachment ID fallback, got %#v", payload["file"])
+	}
+}
+
+func TestDeliverWebhook_Signed(t *testing.T) {
+	var verifyErr error
+	var signed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signed = r.Header.Get(signature.SignatureHeader) != ""
+		_, verifyErr = signature.VerifyRequest(r, "s3cret")
+	}))
+	defer server.Close()
+
+	bot := &Bot{Config: &config.Config{}, WebhookService: NewWebhookService()}
+
+	cmd := &config.CommandSpec{Name: "report", Webhook: config.WebhookSpec{URL: server.URL}, Secret: "s3cret"}
+	if _, _, err := bot.deliverWebhook(cmd, cmd.Targets()[0], map[string]interface{}{"title": "Hello"}); err != nil {
+		t.Fatalf("Expected delivery to succeed, got %v", err)
+	}
+	if verifyErr != nil {
+		t.Errorf("Expected receiver to verify the signature, got %v", verifyErr)
+	}
+
+	cmd.Secret = ""
+	if _, _, err := bot.deliverWebhook(cmd, cmd.Targets()[0], map[string]interface{}{"title": "Hello"}); err != nil {
+		t.Fatalf("Expected delivery to succeed, got %v", err)
+	}
+	if signed {
+		t.Error("Expected no signature without a secret")
+	}
+}
+
+func TestPostWebhook_RequestSettings(t *testing.T) {
+	tests := []struct {
+		name       string
+		webhook    config.WebhookSpec
+		wantMethod string
+		wantHeader string
+		wantValue  string
+	}{
+		{
+			name:       "defaults to POST",
+			webhook:    config.WebhookSpec{},
+			wantMethod: "POST",
+			wantHeader: "Content-Type",
+			wantValue:  "application/json",
+		},
+		{
+			name:       "custom method and header",
+			webhook:    config.WebhookSpec{Method: "PUT", Headers: map[string]string{"X-Team": "ops"}},
+			wantMethod: "PUT",
+			wantHeader: "X-Team",
+			wantValue:  "ops",
+		},
+		{
+			name:       "bearer auth",
+			webhook:    config.WebhookSpec{Auth: &config.AuthSpec{Type: "bearer", Token: "abc"}},
+			wantMethod: "POST",
+			wantHeader: "Authorization",
+			wantValue:  "Bearer abc",
+		},
+		{
+			name:       "basic auth",
+			webhook:    config.WebhookSpec{Auth: &config.AuthSpec{Type: "basic", Username: "bot", Password: "pw"}},
+			wantMethod: "POST",
+			wantHeader: "Authorization",
+			wantValue:  "Basic Ym90OnB3",
+		},
+		{
+			name:       "header auth",
+			webhook:    config.WebhookSpec{Auth: &config.AuthSpec{Type: "header", Header: "X-Api-Key", Value: "secret"}},
+			wantMethod: "POST",
+			wantHeader: "X-Api-Key",
+			wantValue:  "secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var method, value string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				method = r.Method
+				value = r.Header.Get(tt.wantHeader)
+			}))
+			defer server.Close()
+
+			webhook := tt.webhook
+			webhook.URL = server.URL
+			if _, _, _, err := NewWebhookService().postWebhook(webhook, []byte(`{}`), jsonContentType, config.DefaultRetryPolicy, ""); err != nil {
+				t.Fatalf("Expected delivery to succeed, got %v", err)
+			}
+
+			if method != tt.wantMethod {
+				t.Errorf("Expected method %s, got %s", tt.wantMethod, method)
+			}
+			if value != tt.wantValue {
+				t.Errorf("Expected %s '%s', got '%s'", tt.wantHeader, tt.wantValue, value)
+			}
+		})
+	}
+}
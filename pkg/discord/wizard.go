@@ -0,0 +1,241 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// maxModalRows is the number of inputs Discord allows in a single modal.
+	// Longer forms are split across several pages.
+	maxModalRows = 5
+
+	// maxModalTitle is the longest modal title Discord accepts.
+	maxModalTitle = 45
+
+	// wizardTTL is how long the answers of an unfinished multi-page form are
+	// kept.
+	wizardTTL = 15 * time.Minute
+
+	wizardButtonPrefix = "wizard_"
+
+	wizardExpiredMessage = "⌛ This form is no longer available. Please run the command again."
+)
+
+var errWizardExpired = errors.New("form session expired")
+
+// wizardSession holds the answers of a multi-page modal form until its last
+// page is submitted.
+type wizardSession struct {
+	CommandName string
+	GuildID     string
+	UserID      string
+	NextPage    int
+	Data        map[string]interface{}
+	Expires     time.Time
+}
+
+// modalPages splits fields into pages that each fit in one modal.
+func modalPages(fields []config.FieldSpec) [][]config.FieldSpec {
+	var pages [][]config.FieldSpec
+	for start := 0; start < len(fields); start += maxModalRows {
+		end := min(start+maxModalRows, len(fields))
+		pages = append(pages, fields[start:end])
+	}
+	if len(pages) == 0 {
+		pages = append(pages, nil)
+	}
+	return pages
+}
+
+// modalTitle is the title of one page of a command's modal. The command name
+// is shortened when needed so the title fits, keeping the page counter of
+// multi-page forms.
+func modalTitle(commandName string, page, pages int) string {
+	suffix := ""
+	if pages > 1 {
+		suffix = fmt.Sprintf(" (%d/%d)", page+1, pages)
+	}
+	prefix := "Form: "
+	return prefix + truncate(commandName, maxModalTitle-len(prefix)-len(suffix)) + suffix
+}
+
+// wizardModalID is the custom ID of one page of a multi-page modal. Single
+// page modals keep the plain "modal_<command>" ID.
+func wizardModalID(commandName, sessionID string, page int) string {
+	return fmt.Sprintf("modal_%s:%s:%d", commandName, sessionID, page)
+}
+
+// parseModalCustomID splits a modal custom ID, without its "modal_" prefix,
+// into the command name and, for multi-page modals, the session and page.
+func parseModalCustomID(id string) (commandName, sessionID string, page int) {
+	parts := strings.Split(id, ":")
+	if len(parts) != 3 {
+		return id, "", 0
+	}
+	page, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return id, "", 0
+	}
+	return parts[0], parts[1], page
+}
+
+// startWizard opens a session for a multi-page form, keyed by the ID of the
+// interaction that invoked the command.
+func (b *Bot) startWizard(i *discordgo.InteractionCreate, cmd *config.CommandSpec) string {
+	userID := ""
+	if user := interactionUser(i); user != nil {
+		userID = user.ID
+	}
+
+	b.wizardMu.Lock()
+	defer b.wizardMu.Unlock()
+
+	if b.wizards == nil {
+		b.wizards = make(map[string]*wizardSession)
+	}
+
+	now := time.Now()
+	for id, session := range b.wizards {
+		if now.After(session.Expires) {
+			delete(b.wizards, id)
+		}
+	}
+
+	b.wizards[i.ID] = &wizardSession{
+		CommandName: cmd.Name,
+		GuildID:     i.GuildID,
+		UserID:      userID,
+		Data:        make(map[string]interface{}),
+		Expires:     now.Add(wizardTTL),
+	}
+
+	return i.ID
+}
+
+// wizardSessionFor returns the live session sessionID if it belongs to userID.
+// b.wizardMu must be held.
+func (b *Bot) wizardSessionFor(sessionID, userID string) (*wizardSession, error) {
+	session, ok := b.wizards[sessionID]
+	if !ok || time.Now().After(session.Expires) {
+		delete(b.wizards, sessionID)
+		return nil, errWizardExpired
+	}
+	if session.UserID != userID {
+		return nil, fmt.Errorf("form session belongs to another user")
+	}
+	return session, nil
+}
+
+// saveWizardPage stores the answers of one page and returns everything
+// answered so far. Pages must be submitted in order.
+func (b *Bot) saveWizardPage(sessionID, userID string, page int, formData map[string]interface{}) (map[string]interface{}, error) {
+	b.wizardMu.Lock()
+	defer b.wizardMu.Unlock()
+
+	session, err := b.wizardSessionFor(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if page != session.NextPage {
+		return nil, fmt.Errorf("expected page %d, got page %d", session.NextPage+1, page+1)
+	}
+
+	maps.Copy(session.Data, formData)
+	session.NextPage++
+
+	return maps.Clone(session.Data), nil
+}
+
+// nextWizardPage returns a copy of the session, whose NextPage is the page to
+// open next.
+func (b *Bot) nextWizardPage(sessionID, userID string) (wizardSession, error) {
+	b.wizardMu.Lock()
+	defer b.wizardMu.Unlock()
+
+	session, err := b.wizardSessionFor(sessionID, userID)
+	if err != nil {
+		return wizardSession{}, err
+	}
+	return *session, nil
+}
+
+func (b *Bot) endWizard(sessionID string) {
+	b.wizardMu.Lock()
+	defer b.wizardMu.Unlock()
+
+	delete(b.wizards, sessionID)
+}
+
+// handleComponent handles button clicks on messages posted by the bot.
+func (b *Bot) handleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.MessageComponentData().CustomID
+	if strings.HasPrefix(customID, wizardButtonPrefix) {
+		b.handleWizardContinue(s, i, strings.TrimPrefix(customID, wizardButtonPrefix))
+	}
+}
+
+// handleWizardContinue opens the next page of a multi-page form when its
+// "Continue" button is clicked.
+func (b *Bot) handleWizardContinue(s *discordgo.Session, i *discordgo.InteractionCreate, sessionID string) {
+	userID := ""
+	if user := interactionUser(i); user != nil {
+		userID = user.ID
+	}
+
+	session, err := b.nextWizardPage(sessionID, userID)
+	if err != nil {
+		log.Printf("Cannot continue form session %s: %v", sessionID, err)
+		b.respondWithError(s, i, wizardExpiredMessage)
+		return
+	}
+
+	commandSpec := b.findCommandSpec(session.CommandName, session.GuildID)
+	if commandSpec == nil {
+		log.Printf("Unknown command: %s", session.CommandName)
+		b.respondWithError(s, i, "Error: Unknown command")
+		return
+	}
+
+	pages := modalPages(commandSpec.Fields)
+	if session.NextPage >= len(pages) {
+		log.Printf("Form session %s has no page %d", sessionID, session.NextPage+1)
+		b.respondWithError(s, i, wizardExpiredMessage)
+		return
+	}
+
+	err = b.respondWithModalPage(s, i, commandSpec, wizardModalID(commandSpec.Name, sessionID, session.NextPage), session.NextPage, pages)
+	if err != nil {
+		log.Printf("Error opening page %d of %s: %v", session.NextPage+1, commandSpec.Name, err)
+		b.respondWithError(s, i, "Error: Internal error processing command")
+	}
+}
+
+// continueMessage tells the user a page was saved and more pages follow.
+func continueMessage(nextPage, pageCount int) string {
+	return fmt.Sprintf("📄 **Page %d of %d saved.** Click **Continue** to fill in the next page.", nextPage, pageCount)
+}
+
+// continueComponents holds the button that opens the next page of a form.
+func continueComponents(sessionID string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Continue",
+					Style:    discordgo.PrimaryButton,
+					CustomID: wizardButtonPrefix + sessionID,
+				},
+			},
+		},
+	}
+}
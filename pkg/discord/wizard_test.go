@@ -0,0 +1,141 @@
+package discord
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"yambot/pkg/config"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestModalPages(t *testing.T) {
+	var fields []config.FieldSpec
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		fields = append(fields, config.FieldSpec{Name: name, Type: "text"})
+	}
+
+	pages := modalPages(fields)
+	if len(pages) != 2 {
+		t.Fatalf("Expected 2 pages, got %d", len(pages))
+	}
+	if len(pages[0]) != 5 || len(pages[1]) != 2 {
+		t.Errorf("Expected pages of 5 and 2 fields, got %d and %d", len(pages[0]), len(pages[1]))
+	}
+	if pages[1][0].Name != "f" {
+		t.Errorf("Expected second page to start with f, got %s", pages[1][0].Name)
+	}
+
+	if pages := modalPages(fields[:5]); len(pages) != 1 {
+		t.Errorf("Expected 5 fields to fit on 1 page, got %d pages", len(pages))
+	}
+}
+
+func TestModalTitle(t *testing.T) {
+	name := strings.Repeat("n", 32)
+
+	tests := []struct {
+		page, pages int
+		suffix      string
+	}{
+		{0, 1, name},
+		{1, 2, " (2/2)"},
+		{11, 12, " (12/12)"},
+	}
+
+	for _, tt := range tests {
+		title := modalTitle(name, tt.page, tt.pages)
+		if length := utf8.RuneCountInString(title); length > maxModalTitle {
+			t.Errorf("Expected at most %d characters, got %d in '%s'", maxModalTitle, length, title)
+		}
+		if !strings.HasPrefix(title, "Form: n") || !strings.HasSuffix(title, tt.suffix) {
+			t.Errorf("Expected the name and '%s' in the title, got '%s'", tt.suffix, title)
+		}
+	}
+
+	if title := modalTitle("report", 0, 2); title != "Form: report (1/2)" {
+		t.Errorf("Expected short names to be kept, got '%s'", title)
+	}
+}
+
+func TestParseModalCustomID(t *testing.T) {
+	tests := []struct {
+		id        string
+		command   string
+		sessionID string
+		page      int
+	}{
+		{"feedback", "feedback", "", 0},
+		{"onboarding:123456789012345678:1", "onboarding", "123456789012345678", 1},
+		{"onboarding:123:x", "onboarding:123:x", "", 0},
+	}
+
+	for _, tt := range tests {
+		command, sessionID, page := parseModalCustomID(tt.id)
+		if command != tt.command || sessionID != tt.sessionID || page != tt.page {
+			t.Errorf("parseModalCustomID(%q) = %q, %q, %d; expected %q, %q, %d", tt.id, command, sessionID, page, tt.command, tt.sessionID, tt.page)
+		}
+	}
+
+	if id := wizardModalID("onboarding", "42", 2); id != "modal_onboarding:42:2" {
+		t.Errorf("Expected modal_onboarding:42:2, got %s", id)
+	}
+}
+
+func TestWizardSession(t *testing.T) {
+	bot := &Bot{}
+	cmd := &config.CommandSpec{Name: "onboarding"}
+	i := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:     "900000000000000001",
+		Member: &discordgo.Member{User: &discordgo.User{ID: "200000000000000001"}},
+	}}
+
+	sessionID := bot.startWizard(i, cmd)
+
+	if _, err := bot.saveWizardPage(sessionID, "200000000000000002", 0, map[string]interface{}{"a": "x"}); err == nil {
+		t.Error("Expected another user's page to be rejected")
+	}
+	if _, err := bot.saveWizardPage(sessionID, "200000000000000001", 1, map[string]interface{}{"f": "y"}); err == nil {
+		t.Error("Expected an out of order page to be rejected")
+	}
+
+	if _, err := bot.saveWizardPage(sessionID, "200000000000000001", 0, map[string]interface{}{"a": "x"}); err != nil {
+		t.Fatalf("Expected first page to be saved, got %v", err)
+	}
+
+	session, err := bot.nextWizardPage(sessionID, "200000000000000001")
+	if err != nil || session.NextPage != 1 {
+		t.Fatalf("Expected next page 1, got %d (%v)", session.NextPage, err)
+	}
+
+	merged, err := bot.saveWizardPage(sessionID, "200000000000000001", 1, map[string]interface{}{"f": "y"})
+	if err != nil {
+		t.Fatalf("Expected second page to be saved, got %v", err)
+	}
+	if merged["a"] != "x" || merged["f"] != "y" {
+		t.Errorf("Expected answers of both pages, got %v", merged)
+	}
+
+	bot.endWizard(sessionID)
+	if _, err := bot.nextWizardPage(sessionID, "200000000000000001"); !errors.Is(err, errWizardExpired) {
+		t.Errorf("Expected ended session to be gone, got %v", err)
+	}
+}
+
+func TestWizardSession_Expired(t *testing.T) {
+	bot := &Bot{}
+	i := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:   "900000000000000001",
+		User: &discordgo.User{ID: "200000000000000001"},
+	}}
+
+	sessionID := bot.startWizard(i, &config.CommandSpec{Name: "onboarding"})
+	bot.wizards[sessionID].Expires = time.Now().Add(-time.Second)
+
+	if _, err := bot.saveWizardPage(sessionID, "200000000000000001", 0, nil); !errors.Is(err, errWizardExpired) {
+		t.Errorf("Expected expired session error, got %v", err)
+	}
+}
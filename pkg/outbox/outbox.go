@@ -0,0 +1,222 @@
+// Package outbox keeps webhook payloads on disk until they are delivered, so
+// submissions survive restarts and endpoint outages.
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// FileName is the name of the database file inside the outbox directory.
+const FileName = "outbox.db"
+
+var (
+	pendingBucket = []byte("pending")
+	deadBucket    = []byte("dead")
+)
+
+// ErrNotFound is returned for an entry ID that is not in the outbox.
+var ErrNotFound = errors.New("outbox entry not found")
+
+// Entry is a webhook payload waiting for delivery.
+type Entry struct {
+	ID      string `json:"id"`
+	Command string `json:"command"`
+	// Target names the command's destination, for commands with several.
+	Target  string          `json:"target,omitempty"`
+	URL     string          `json:"url"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	// Body and ContentType hold payloads that are not JSON, such as
+	// multipart uploads; Payload is empty for them.
+	Body        []byte `json:"body,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	// Attempts counts the delivery attempts made so far.
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	NextAttempt time.Time `json:"next_attempt"`
+}
+
+// Store is an outbox backed by a bbolt database. Undelivered entries are
+// pending; entries that keep failing are moved to the dead-letter list, from
+// where they can be replayed.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the outbox in dir. The database is locked while it is
+// open, so only one process can use it at a time.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+
+	db, err := bolt.Open(filepath.Join(dir, FileName), 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outbox: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{pendingBucket, deadBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialise outbox: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Enqueue stores a new pending entry, assigning its ID. Entries are due
+// immediately unless NextAttempt is set.
+func (s *Store) Enqueue(entry *Entry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(pendingBucket)
+
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		// Zero-padded IDs keep the bucket in submission order
+		entry.ID = fmt.Sprintf("%012d", seq)
+		if entry.CreatedAt.IsZero() {
+			entry.CreatedAt = time.Now()
+		}
+		if entry.NextAttempt.IsZero() {
+			entry.NextAttempt = entry.CreatedAt
+		}
+
+		return putEntry(bucket, *entry)
+	})
+}
+
+// Delete removes a pending entry, typically once it has been delivered.
+func (s *Store) Delete(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(pendingBucket).Delete([]byte(id))
+	})
+}
+
+// Reschedule stores the updated attempt count, error and next attempt time of
+// a pending entry. It fails with ErrNotFound once the entry is no longer
+// pending.
+func (s *Store) Reschedule(entry Entry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(pendingBucket)
+		if bucket.Get([]byte(entry.ID)) == nil {
+			return ErrNotFound
+		}
+		return putEntry(bucket, entry)
+	})
+}
+
+// Bury moves a pending entry to the dead-letter list. Entries without an ID
+// were never enqueued and are buried right away, so payloads that fail
+// permanently need not be stored first. Burying an entry that is no longer
+// pending, for example because it was delivered meanwhile, fails with
+// ErrNotFound.
+func (s *Store) Bury(entry Entry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		pending := tx.Bucket(pendingBucket)
+		if entry.ID == "" {
+			seq, err := pending.NextSequence()
+			if err != nil {
+				return err
+			}
+			entry.ID = fmt.Sprintf("%012d", seq)
+		} else if pending.Get([]byte(entry.ID)) == nil {
+			return ErrNotFound
+		}
+		if err := pending.Delete([]byte(entry.ID)); err != nil {
+			return err
+		}
+		return putEntry(tx.Bucket(deadBucket), entry)
+	})
+}
+
+// Replay moves a dead-letter entry back to the pending list with a fresh
+// attempt count, due immediately.
+func (s *Store) Replay(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		dead := tx.Bucket(deadBucket)
+
+		entry, err := getEntry(dead, id)
+		if err != nil {
+			return err
+		}
+
+		entry.Attempts = 0
+		entry.NextAttempt = time.Now()
+		if err := dead.Delete([]byte(id)); err != nil {
+			return err
+		}
+		return putEntry(tx.Bucket(pendingBucket), entry)
+	})
+}
+
+// Pending lists the entries waiting for delivery, oldest first.
+func (s *Store) Pending() ([]Entry, error) {
+	return s.list(pendingBucket, func(Entry) bool { return true })
+}
+
+// Due lists the pending entries whose next attempt is at or before now.
+func (s *Store) Due(now time.Time) ([]Entry, error) {
+	return s.list(pendingBucket, func(e Entry) bool { return !e.NextAttempt.After(now) })
+}
+
+// Dead lists the dead-letter entries, oldest first.
+func (s *Store) Dead() ([]Entry, error) {
+	return s.list(deadBucket, func(Entry) bool { return true })
+}
+
+func (s *Store) list(name []byte, keep func(Entry) bool) ([]Entry, error) {
+	var entries []Entry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(name).ForEach(func(_, value []byte) error {
+			var entry Entry
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return err
+			}
+			if keep(entry) {
+				entries = append(entries, entry)
+			}
+			return nil
+		})
+	})
+	return entries, err
+}
+
+func putEntry(bucket *bolt.Bucket, entry Entry) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(entry.ID), value)
+}
+
+func getEntry(bucket *bolt.Bucket, id string) (Entry, error) {
+	value := bucket.Get([]byte(id))
+	if value == nil {
+		return Entry{}, ErrNotFound
+	}
+	var entry Entry
+	err := json.Unmarshal(value, &entry)
+	return entry, err
+}
@@ -0,0 +1,196 @@
+package outbox
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open outbox: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestStore_Lifecycle(t *testing.T) {
+	store := openTestStore(t)
+
+	first := &Entry{Command: "report", URL: "https://example.com/a", Payload: []byte(`{"a":1}`)}
+	second := &Entry{Command: "report", URL: "https://example.com/b", Payload: []byte(`{"b":2}`), NextAttempt: time.Now().Add(time.Hour)}
+	for _, entry := range []*Entry{first, second} {
+		if err := store.Enqueue(entry); err != nil {
+			t.Fatalf("Failed to enqueue: %v", err)
+		}
+	}
+	if first.ID == "" || first.ID >= second.ID {
+		t.Errorf("Expected increasing IDs, got %q and %q", first.ID, second.ID)
+	}
+
+	due, err := store.Due(time.Now())
+	if err != nil || len(due) != 1 || due[0].ID != first.ID {
+		t.Fatalf("Expected only the first entry to be due, got %v (%v)", due, err)
+	}
+	if string(due[0].Payload) != `{"a":1}` {
+		t.Errorf("Expected payload to be kept, got %s", due[0].Payload)
+	}
+
+	if err := store.Bury(due[0]); err != nil {
+		t.Fatalf("Failed to bury: %v", err)
+	}
+	pending, _ := store.Pending()
+	dead, _ := store.Dead()
+	if len(pending) != 1 || len(dead) != 1 || dead[0].ID != first.ID {
+		t.Fatalf("Expected 1 pending and 1 dead entry, got %d and %d", len(pending), len(dead))
+	}
+
+	if err := store.Replay(first.ID); err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	if err := store.Replay(first.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected replaying twice to fail with ErrNotFound, got %v", err)
+	}
+	dead, _ = store.Dead()
+	if len(dead) != 0 {
+		t.Errorf("Expected no dead entries after replay, got %d", len(dead))
+	}
+
+	if err := store.Delete(first.ID); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if err := store.Reschedule(*first); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected rescheduling a deleted entry to fail, got %v", err)
+	}
+}
+
+func TestStore_SurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Failed to open outbox: %v", err)
+	}
+	if err := store.Enqueue(&Entry{Command: "report", URL: "https://example.com", Payload: []byte(`{}`)}); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	store.Close()
+
+	store, err = Open(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen outbox: %v", err)
+	}
+	defer store.Close()
+
+	pending, err := store.Pending()
+	if err != nil || len(pending) != 1 {
+		t.Errorf("Expected the entry to survive a reopen, got %v (%v)", pending, err)
+	}
+}
+
+func TestWorker_Flush(t *testing.T) {
+	store := openTestStore(t)
+
+	results := map[string]struct {
+		retry bool
+		err   error
+	}{
+		"ok":        {false, nil},
+		"flaky":     {true, errors.New("webhook returned status 503")},
+		"broken":    {false, errors.New("webhook returned status 400")},
+		"exhausted": {true, errors.New("failed to send webhook")},
+	}
+	for _, command := range []string{"ok", "flaky", "broken", "exhausted"} {
+		entry := &Entry{Command: command, URL: "https://example.com", Payload: []byte(`{}`)}
+		if command == "exhausted" {
+			entry.Attempts = 2
+		}
+		if err := store.Enqueue(entry); err != nil {
+			t.Fatalf("Failed to enqueue: %v", err)
+		}
+	}
+
+	worker := &Worker{
+		Store: store,
+		Deliver: func(entry Entry) (bool, error) {
+			result := results[entry.Command]
+			return result.retry, result.err
+		},
+		Interval:    time.Minute,
+		MaxAttempts: 3,
+	}
+
+	now := time.Now()
+	worker.Flush(now)
+
+	pending, _ := store.Pending()
+	if len(pending) != 1 || pending[0].Command != "flaky" {
+		t.Fatalf("Expected only the flaky entry to stay pending, got %v", pending)
+	}
+	if pending[0].Attempts != 1 || !pending[0].NextAttempt.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected flaky entry rescheduled after 1 attempt, got %d attempts, next at %s", pending[0].Attempts, pending[0].NextAttempt)
+	}
+
+	dead, _ := store.Dead()
+	if len(dead) != 2 || dead[0].Command != "broken" || dead[1].Command != "exhausted" {
+		t.Fatalf("Expected broken and exhausted entries to be dead-lettered, got %v", dead)
+	}
+	if dead[0].LastError != "webhook returned status 400" {
+		t.Errorf("Expected last error to be recorded, got %q", dead[0].LastError)
+	}
+}
+
+func TestWorker_FlushEntryGoneDuringAttempt(t *testing.T) {
+	store := openTestStore(t)
+
+	for _, command := range []string{"flaky", "broken"} {
+		if err := store.Enqueue(&Entry{Command: command, URL: "https://example.com", Payload: []byte(`{}`)}); err != nil {
+			t.Fatalf("Failed to enqueue: %v", err)
+		}
+	}
+
+	// Both entries are delivered elsewhere while the worker's attempt fails
+	worker := &Worker{
+		Store: store,
+		Deliver: func(entry Entry) (bool, error) {
+			if err := store.Delete(entry.ID); err != nil {
+				t.Fatalf("Failed to delete: %v", err)
+			}
+			return entry.Command == "flaky", errors.New("failed to send webhook")
+		},
+		Interval:    time.Minute,
+		MaxAttempts: 3,
+	}
+	worker.Flush(time.Now())
+
+	pending, _ := store.Pending()
+	dead, _ := store.Dead()
+	if len(pending) != 0 || len(dead) != 0 {
+		t.Errorf("Expected delivered entries to stay gone, got %d pending and %d dead", len(pending), len(dead))
+	}
+	if err := store.Bury(Entry{ID: "000000000001", Command: "flaky"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected burying a delivered entry to fail with ErrNotFound, got %v", err)
+	}
+}
+
+func TestWorker_Backoff(t *testing.T) {
+	worker := &Worker{Interval: 30 * time.Second}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{20, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := worker.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, expected %s", tt.attempts, got, tt.want)
+		}
+	}
+}
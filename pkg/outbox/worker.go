@@ -0,0 +1,90 @@
+package outbox
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+// maxBackoff caps the wait between two background attempts of one entry.
+const maxBackoff = time.Hour
+
+// DeliverFunc makes a single delivery attempt for an entry. When it fails,
+// retry reports whether a later attempt may succeed.
+type DeliverFunc func(entry Entry) (retry bool, err error)
+
+// Worker keeps delivering pending entries in the background. Entries that
+// fail are retried with a growing delay until MaxAttempts is reached, then
+// moved to the dead-letter list.
+type Worker struct {
+	Store   *Store
+	Deliver DeliverFunc
+	// Interval is how often due entries are looked for, and the delay before
+	// the first background retry.
+	Interval time.Duration
+	// MaxAttempts is the number of attempts after which an entry is given up.
+	MaxAttempts int
+}
+
+// Run delivers due entries until stop is closed.
+func (w *Worker) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	w.Flush(time.Now())
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			w.Flush(now)
+		}
+	}
+}
+
+// Flush makes one delivery attempt for every entry due at now.
+func (w *Worker) Flush(now time.Time) {
+	entries, err := w.Store.Due(now)
+	if err != nil {
+		log.Printf("Error reading outbox: %v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		retry, err := w.Deliver(entry)
+		entry.Attempts++
+
+		switch {
+		case err == nil:
+			log.Printf("Delivered outbox entry %s for %s after %d attempts", entry.ID, entry.Command, entry.Attempts)
+			err = w.Store.Delete(entry.ID)
+		case !retry || entry.Attempts >= w.MaxAttempts:
+			log.Printf("Giving up on outbox entry %s for %s after %d attempts: %v", entry.ID, entry.Command, entry.Attempts, err)
+			entry.LastError = err.Error()
+			err = w.Store.Bury(entry)
+		default:
+			entry.LastError = err.Error()
+			entry.NextAttempt = now.Add(w.backoff(entry.Attempts))
+			log.Printf("Outbox entry %s for %s failed (%v), next attempt at %s", entry.ID, entry.Command, err, entry.NextAttempt.Format(time.RFC3339))
+			err = w.Store.Reschedule(entry)
+		}
+
+		switch {
+		case errors.Is(err, ErrNotFound):
+			// Delivered or buried by someone else during the attempt, which
+			// must not bring it back
+			log.Printf("Outbox entry %s for %s is no longer pending, leaving it", entry.ID, entry.Command)
+		case err != nil:
+			log.Printf("Error updating outbox entry %s: %v", entry.ID, err)
+		}
+	}
+}
+
+// backoff doubles the interval with every failed attempt, up to maxBackoff.
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := w.Interval
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
@@ -0,0 +1,107 @@
+// Package signature signs yambot's webhook requests and lets receivers verify
+// them.
+//
+// A signed request carries two headers: X-Yambot-Timestamp with the Unix time
+// the request was sent at, and X-Yambot-Signature with "sha256=" followed by
+// the hex-encoded HMAC-SHA256 of "<timestamp>.<body>", keyed with the
+// command's secret. Receivers recompute the HMAC over the raw request body and
+// reject requests whose timestamp is too old, which stops replays.
+package signature
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TimestampHeader = "X-Yambot-Timestamp"
+	SignatureHeader = "X-Yambot-Signature"
+
+	// DefaultTolerance is how far a request's timestamp may be from the
+	// receiver's clock.
+	DefaultTolerance = 5 * time.Minute
+
+	prefix = "sha256="
+)
+
+var (
+	ErrMissingHeaders   = errors.New("signature headers missing")
+	ErrInvalidTimestamp = errors.New("invalid signature timestamp")
+	ErrExpired          = errors.New("signature timestamp outside tolerance")
+	ErrMismatch         = errors.New("signature mismatch")
+)
+
+// Sign returns the signature header value for a body sent at timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return prefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SetHeaders adds the timestamp and signature headers for body to h.
+func SetHeaders(h http.Header, secret string, now time.Time, body []byte) {
+	timestamp := now.Unix()
+	h.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	h.Set(SignatureHeader, Sign(secret, timestamp, body))
+}
+
+// Verify checks the signature headers in h against body. A tolerance of zero
+// means DefaultTolerance.
+func Verify(secret string, h http.Header, body []byte, now time.Time, tolerance time.Duration) error {
+	rawTimestamp := h.Get(TimestampHeader)
+	signature := h.Get(SignatureHeader)
+	if rawTimestamp == "" || signature == "" {
+		return ErrMissingHeaders
+	}
+
+	timestamp, err := strconv.ParseInt(rawTimestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
+	age := now.Sub(time.Unix(timestamp, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrExpired
+	}
+
+	if !strings.HasPrefix(signature, prefix) {
+		return ErrMismatch
+	}
+	expected := Sign(secret, timestamp, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrMismatch
+	}
+
+	return nil
+}
+
+// VerifyRequest reads and verifies the body of an incoming webhook request
+// with DefaultTolerance. It returns the body, which is also put back on the
+// request so handlers can read it again.
+func VerifyRequest(r *http.Request, secret string) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := Verify(secret, r.Header, body, time.Now(), DefaultTolerance); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,73 @@
+package signature
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	// printf '1700000000.{"a":1}' | openssl dgst -sha256 -hmac secret
+	want := "sha256=49f24e537407743fa4a0242bb63b94b9a47ee99cbbe071ccd8a22550ae411686"
+	if got := Sign("secret", 1700000000, []byte(`{"a":1}`)); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"title":"Hello"}`)
+
+	signed := http.Header{}
+	SetHeaders(signed, "secret", now, body)
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  http.Header
+		body    []byte
+		now     time.Time
+		wantErr error
+	}{
+		{"valid", "secret", signed, body, now, nil},
+		{"slight clock skew", "secret", signed, body, now.Add(-time.Minute), nil},
+		{"wrong secret", "other", signed, body, now, ErrMismatch},
+		{"tampered body", "secret", signed, []byte(`{"title":"Bye"}`), now, ErrMismatch},
+		{"too old", "secret", signed, body, now.Add(10 * time.Minute), ErrExpired},
+		{"missing headers", "secret", http.Header{}, body, now, ErrMissingHeaders},
+		{"bad timestamp", "secret", http.Header{TimestampHeader: {"soon"}, SignatureHeader: {"sha256=00"}}, body, now, ErrInvalidTimestamp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, tt.now, 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	body := []byte(`{"title":"Hello"}`)
+
+	r := httptest.NewRequest(http.MethodPost, "/hook", bytes.NewReader(body))
+	SetHeaders(r.Header, "secret", time.Now(), body)
+
+	got, err := VerifyRequest(r, "secret")
+	if err != nil {
+		t.Fatalf("Expected valid request, got %v", err)
+	}
+	if !bytes.Equal(got, body) {
+		t.Errorf("Expected body %s, got %s", body, got)
+	}
+
+	again, _ := io.ReadAll(r.Body)
+	if !bytes.Equal(again, body) {
+		t.Errorf("Expected body to be readable again, got %s", again)
+	}
+}